@@ -0,0 +1,41 @@
+package attachment
+
+import "time"
+
+// Attachment is a file stored alongside a note. Images also get their
+// dimensions and thumbnails, other files leave ThumbnailStatus empty.
+type Attachment struct {
+	ID              int
+	NoteID          int
+	UserID          int
+	Filename        string
+	ContentType     string
+	Size            int64
+	StorageKey      string
+	Width           int
+	Height          int
+	ThumbnailStatus string
+	Thumbnails      []Thumbnail
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+type Thumbnail struct {
+	ID           int
+	AttachmentID int
+	Size         string
+	Width        int
+	Height       int
+	ContentType  string
+	StorageKey   string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Usage is how much storage a user's attachments take up against their
+// quota. A zero Quota means there's no limit.
+type Usage struct {
+	Used    int64
+	Quota   int64
+	MaxSize int64
+}
@@ -0,0 +1,13 @@
+package attachment
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var (
+	ErrNotFound          = helper.NewError(helper.ErrNotFound, "attachment_not_found", "attachment doesn't exist")
+	ErrThumbnailNotFound = helper.NewError(helper.ErrNotFound, "thumbnail_not_found", "thumbnail doesn't exist or isn't ready yet")
+	ErrInvalidImage      = helper.NewError(helper.ErrValidation, "invalid_image", "file isn't a readable image")
+	ErrEmptyFile         = helper.NewError(helper.ErrValidation, "empty_attachment", "the uploaded file is empty")
+	ErrTypeNotAllowed    = helper.NewError(helper.ErrValidation, "attachment_type_not_allowed", "this type of file can't be attached")
+	ErrTooLarge          = helper.NewError(helper.ErrTooLarge, "attachment_too_large", "file is larger than the allowed attachment size")
+	ErrQuotaExceeded     = helper.NewError(helper.ErrForbidden, "storage_quota_exceeded", "file doesn't fit in the remaining storage")
+)
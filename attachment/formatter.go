@@ -0,0 +1,112 @@
+package attachment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+type AttachmentFormatter struct {
+	ID              int                  `json:"id"`
+	NoteID          int                  `json:"note_id"`
+	Filename        string               `json:"filename"`
+	ContentType     string               `json:"content_type"`
+	Size            int64                `json:"size"`
+	Width           int                  `json:"width,omitempty"`
+	Height          int                  `json:"height,omitempty"`
+	URL             string               `json:"url"`
+	ThumbnailStatus string               `json:"thumbnail_status,omitempty"`
+	Thumbnails      []ThumbnailFormatter `json:"thumbnails,omitempty"`
+	CreatedAt       time.Time            `json:"created_at"`
+}
+
+type ThumbnailFormatter struct {
+	Size   string `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+// NoteFormatter is the single note view, which lists the note's attachments
+// alongside it.
+type NoteFormatter struct {
+	note.NoteFormatter
+	Attachments []AttachmentFormatter `json:"attachments"`
+}
+
+type UsageFormatter struct {
+	Used          int64  `json:"used"`
+	Quota         *int64 `json:"quota"`
+	Remaining     *int64 `json:"remaining"`
+	MaxAttachment *int64 `json:"max_attachment_size"`
+}
+
+func FormatAttachment(attachment Attachment) AttachmentFormatter {
+	attachmentFormatter := AttachmentFormatter{
+		ID:              attachment.ID,
+		NoteID:          attachment.NoteID,
+		Filename:        attachment.Filename,
+		ContentType:     attachment.ContentType,
+		Size:            attachment.Size,
+		Width:           attachment.Width,
+		Height:          attachment.Height,
+		URL:             AttachmentURL(attachment.NoteID, attachment.ID),
+		ThumbnailStatus: attachment.ThumbnailStatus,
+		CreatedAt:       attachment.CreatedAt,
+	}
+
+	for _, thumbnail := range attachment.Thumbnails {
+		attachmentFormatter.Thumbnails = append(attachmentFormatter.Thumbnails, ThumbnailFormatter{
+			Size:   thumbnail.Size,
+			Width:  thumbnail.Width,
+			Height: thumbnail.Height,
+			URL:    ThumbnailURL(attachment.NoteID, attachment.ID, thumbnail.Size),
+		})
+	}
+
+	return attachmentFormatter
+}
+
+func FormatAttachments(attachments []Attachment) []AttachmentFormatter {
+	attachmentFormatters := []AttachmentFormatter{}
+
+	for _, attachment := range attachments {
+		attachmentFormatters = append(attachmentFormatters, FormatAttachment(attachment))
+	}
+
+	return attachmentFormatters
+}
+
+func FormatNote(fetchedNote note.Note, attachments []Attachment) NoteFormatter {
+	return NoteFormatter{note.FormatNote(fetchedNote), FormatAttachments(attachments)}
+}
+
+// AttachmentURL is where the attachment is downloaded from, relative to the
+// API's host.
+func AttachmentURL(noteID, attachmentID int) string {
+	return fmt.Sprintf("/api/v1/notes/%d/attachments/%d", noteID, attachmentID)
+}
+
+func ThumbnailURL(noteID, attachmentID int, size string) string {
+	return fmt.Sprintf("%s/thumbnails/%s", AttachmentURL(noteID, attachmentID), size)
+}
+
+// FormatUsage leaves the limits that aren't set as null.
+func FormatUsage(usage Usage) UsageFormatter {
+	usageFormatter := UsageFormatter{Used: usage.Used}
+
+	if usage.Quota > 0 {
+		quota := usage.Quota
+		remaining := max(usage.Quota-usage.Used, 0)
+		usageFormatter.Quota = &quota
+		usageFormatter.Remaining = &remaining
+	}
+
+	if usage.MaxSize > 0 {
+		maxSize := usage.MaxSize
+		usageFormatter.MaxAttachment = &maxSize
+	}
+
+	return usageFormatter
+}
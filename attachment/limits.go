@@ -0,0 +1,67 @@
+package attachment
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Limits bounds what can be uploaded. MaxSize caps a single file and Quota
+// the total per user, both in bytes, with zero meaning no limit. AllowedTypes
+// lists MIME types such as application/pdf or image/*, an empty list allows
+// any type.
+type Limits struct {
+	MaxSize      int64
+	Quota        int64
+	AllowedTypes []string
+}
+
+func (l Limits) allowsType(contentType string) bool {
+	if len(l.AllowedTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range l.AllowedTypes {
+		if allowed == contentType {
+			return true
+		}
+
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// genericTypes are what sniffing reports for containers it can't look into,
+// mapped to the kind of type the file's extension may refine them to.
+// Unrecognised binary stays application/octet-stream whatever its name, so
+// an extension alone can't pass a file off as an allowed type.
+var genericTypes = map[string]string{
+	"application/zip": "application/",
+	"text/plain":      "text/",
+}
+
+// detectType tells the file's type from its first bytes rather than trusting
+// what the client claims. Where sniffing can only give a generic type, such
+// as a .docx reading as a zip, the extension refines it.
+func detectType(head []byte, filename string) string {
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	refinable, ok := genericTypes[contentType]
+	if !ok {
+		return contentType
+	}
+
+	byExtension, _, err := mime.ParseMediaType(mime.TypeByExtension(strings.ToLower(path.Ext(filename))))
+	if err != nil || !strings.HasPrefix(byExtension, refinable) {
+		return contentType
+	}
+
+	return byExtension
+}
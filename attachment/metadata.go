@@ -0,0 +1,221 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedImage = errors.New("malformed image")
+
+// stripMetadata drops EXIF, XMP, IPTC and text metadata, which can hold the
+// camera, the time and the place a photo was taken. It works on the encoded
+// bytes so the image itself isn't recompressed. A JPEG keeps its orientation,
+// since without it a rotated photo would display sideways.
+func stripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+
+	return data, nil
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformedImage
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2])
+
+	orientation := 1
+	for offset := 2; ; {
+		if offset+4 > len(data) || data[offset] != 0xFF {
+			return nil, errMalformedImage
+		}
+
+		marker := data[offset+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			offset++
+			continue
+		}
+
+		// Start of scan, what follows is the image data itself
+		if marker == 0xDA {
+			if orientation != 1 {
+				out.Write(orientationSegment(orientation))
+			}
+			out.Write(data[offset:])
+
+			return out.Bytes(), nil
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		end := offset + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		segment := data[offset:end]
+
+		switch {
+		case marker == 0xE1:
+			// APP1 holds EXIF or XMP
+			if o := exifOrientation(segment[4:]); o != 0 {
+				orientation = o
+			}
+		case marker == 0xED || marker == 0xFE:
+			// APP13 holds IPTC, COM free text comments
+		default:
+			out.Write(segment)
+		}
+
+		offset = end
+	}
+}
+
+// exifOrientation reads the orientation tag from an APP1 payload, returning
+// 0 when it isn't EXIF or has no valid orientation.
+func exifOrientation(payload []byte) int {
+	tiff, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00"))
+	if !ok || len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 0
+			}
+
+			return orientation
+		}
+	}
+
+	return 0
+}
+
+// orientationSegment builds an APP1 segment with an EXIF block holding only
+// the orientation.
+func orientationSegment(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, first IFD right after it
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, // orientation, one SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+
+	return append(segment, payload...)
+}
+
+// pngMetadataChunks hold EXIF, text such as comments or the software used,
+// and the modification time.
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(data []byte) ([]byte, error) {
+	signature := []byte("\x89PNG\r\n\x1a\n")
+	if !bytes.HasPrefix(data, signature) {
+		return nil, errMalformedImage
+	}
+
+	var out bytes.Buffer
+	out.Write(signature)
+
+	for offset := len(signature); offset < len(data); {
+		if offset+12 > len(data) {
+			return nil, errMalformedImage
+		}
+
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		end := offset + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		chunkType := string(data[offset+4 : offset+8])
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[offset:end])
+		}
+
+		offset = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformedImage
+	}
+
+	var chunks bytes.Buffer
+
+	for offset := 12; offset < len(data); {
+		if offset+8 > len(data) {
+			return nil, errMalformedImage
+		}
+
+		length := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		end := offset + 8 + length + length%2
+		if length < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		switch string(data[offset : offset+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			// Clear the flags saying EXIF and XMP chunks follow
+			chunk := bytes.Clone(data[offset:end])
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+			chunks.Write(chunk)
+		default:
+			chunks.Write(data[offset:end])
+		}
+
+		offset = end
+	}
+
+	out := make([]byte, 12, 12+chunks.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:], uint32(4+chunks.Len()))
+	copy(out[8:], "WEBP")
+
+	return append(out, chunks.Bytes()...), nil
+}
@@ -0,0 +1,249 @@
+package attachment
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
+)
+
+type Repository interface {
+	WithTx(tx *sql.Tx) Repository
+	FindByID(noteID, id int) (Attachment, error)
+	FindByNoteID(noteID int) ([]Attachment, error)
+	FindOrphaned(limit int) ([]Attachment, error)
+	SumSizeByUserID(userID int) (int64, error)
+	LockUser(userID int) error
+	FindPendingThumbnails(afterID, limit int) ([]Attachment, error)
+	Save(attachment Attachment) (Attachment, error)
+	UpdateImage(attachment Attachment) error
+	Delete(attachment Attachment) error
+	FindThumbnails(attachmentIDs []int) ([]Thumbnail, error)
+	FindThumbnail(attachmentID int, size string) (Thumbnail, error)
+	SaveThumbnail(thumbnail Thumbnail) error
+}
+
+const attachmentColumns = "id, COALESCE(note_id, 0), user_id, filename, content_type, size, storage_key, " +
+	"COALESCE(width, 0), COALESCE(height, 0), COALESCE(thumbnail_status, ''), created_at, updated_at"
+
+type repository struct {
+	db transaction.DBTX
+}
+
+func NewRepository(db *sql.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{tx}
+}
+
+func (r *repository) FindByID(noteID, id int) (Attachment, error) {
+	var attachment Attachment
+
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE note_id = ? AND id = ?"
+
+	err := r.db.QueryRow(query, noteID, id).Scan(
+		&attachment.ID, &attachment.NoteID, &attachment.UserID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.StorageKey, &attachment.Width, &attachment.Height, &attachment.ThumbnailStatus,
+		&attachment.CreatedAt, &attachment.UpdatedAt,
+	)
+	if err != nil {
+		return attachment, err
+	}
+
+	return attachment, nil
+}
+
+func (r *repository) FindByNoteID(noteID int) ([]Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE note_id = ? ORDER BY id"
+
+	return r.findAll(query, noteID)
+}
+
+// FindOrphaned finds attachments whose note was purged, which the database
+// unlinks rather than deletes so their blobs can still be cleaned up.
+func (r *repository) FindOrphaned(limit int) ([]Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE note_id IS NULL ORDER BY id LIMIT ?"
+
+	return r.findAll(query, limit)
+}
+
+// FindPendingThumbnails finds images still waiting for their thumbnails,
+// such as those a restart interrupted, in pages following afterID.
+func (r *repository) FindPendingThumbnails(afterID, limit int) ([]Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments " +
+		"WHERE thumbnail_status = 'pending' AND note_id IS NOT NULL AND id > ? ORDER BY id LIMIT ?"
+
+	return r.findAll(query, afterID, limit)
+}
+
+func (r *repository) findAll(query string, args ...any) ([]Attachment, error) {
+	var attachments []Attachment
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return attachments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attachment Attachment
+
+		if err := rows.Scan(
+			&attachment.ID, &attachment.NoteID, &attachment.UserID, &attachment.Filename, &attachment.ContentType,
+			&attachment.Size, &attachment.StorageKey, &attachment.Width, &attachment.Height, &attachment.ThumbnailStatus,
+			&attachment.CreatedAt, &attachment.UpdatedAt,
+		); err != nil {
+			return attachments, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// SumSizeByUserID adds up the user's attachments. Orphaned ones are about to
+// be swept, so they no longer count.
+func (r *repository) SumSizeByUserID(userID int) (int64, error) {
+	var used int64
+
+	query := "SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = ? AND note_id IS NOT NULL"
+
+	err := r.db.QueryRow(query, userID).Scan(&used)
+	return used, err
+}
+
+// LockUser locks the user's row until the transaction ends, so concurrent
+// uploads check the quota one after another.
+func (r *repository) LockUser(userID int) error {
+	var id int
+
+	query := "SELECT id FROM users WHERE id = ? FOR UPDATE"
+
+	return r.db.QueryRow(query, userID).Scan(&id)
+}
+
+func (r *repository) Save(attachment Attachment) (Attachment, error) {
+	var thumbnailStatus any
+	if attachment.ThumbnailStatus != "" {
+		thumbnailStatus = attachment.ThumbnailStatus
+	}
+
+	query := "INSERT INTO attachments SET " +
+		"note_id = ?, user_id = ?, filename = ?, content_type = ?, size = ?, storage_key = ?, thumbnail_status = ?, " +
+		"created_at = NOW(), updated_at = NOW()"
+
+	res, err := r.db.Exec(query,
+		attachment.NoteID, attachment.UserID, attachment.Filename, attachment.ContentType, attachment.Size, attachment.StorageKey,
+		thumbnailStatus,
+	)
+	if err != nil {
+		return attachment, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return attachment, err
+	}
+
+	attachment.ID = int(id)
+	attachment.CreatedAt = time.Now()
+	attachment.UpdatedAt = time.Now()
+
+	return attachment, nil
+}
+
+// UpdateImage records the image's dimensions and how its thumbnails went.
+func (r *repository) UpdateImage(attachment Attachment) error {
+	var width, height any
+	if attachment.Width > 0 && attachment.Height > 0 {
+		width, height = attachment.Width, attachment.Height
+	}
+
+	query := "UPDATE attachments SET width = ?, height = ?, thumbnail_status = ?, updated_at = NOW() WHERE id = ?"
+
+	_, err := r.db.Exec(query, width, height, attachment.ThumbnailStatus, attachment.ID)
+	return err
+}
+
+func (r *repository) Delete(attachment Attachment) error {
+	query := "DELETE FROM attachments WHERE id = ?"
+
+	_, err := r.db.Exec(query, attachment.ID)
+	return err
+}
+
+func (r *repository) FindThumbnails(attachmentIDs []int) ([]Thumbnail, error) {
+	var thumbnails []Thumbnail
+
+	if len(attachmentIDs) == 0 {
+		return thumbnails, nil
+	}
+
+	query := "SELECT id, attachment_id, size, width, height, content_type, storage_key, created_at, updated_at " +
+		"FROM attachment_thumbnails WHERE attachment_id IN (%s) ORDER BY attachment_id, width"
+
+	questionMarks := []string{}
+	fields := []any{}
+	for _, id := range attachmentIDs {
+		questionMarks = append(questionMarks, "?")
+		fields = append(fields, id)
+	}
+
+	query = fmt.Sprintf(query, strings.Join(questionMarks, ","))
+	rows, err := r.db.Query(query, fields...)
+	if err != nil {
+		return thumbnails, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var thumbnail Thumbnail
+
+		if err := rows.Scan(
+			&thumbnail.ID, &thumbnail.AttachmentID, &thumbnail.Size, &thumbnail.Width, &thumbnail.Height,
+			&thumbnail.ContentType, &thumbnail.StorageKey, &thumbnail.CreatedAt, &thumbnail.UpdatedAt,
+		); err != nil {
+			return thumbnails, err
+		}
+
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	return thumbnails, nil
+}
+
+func (r *repository) FindThumbnail(attachmentID int, size string) (Thumbnail, error) {
+	var thumbnail Thumbnail
+
+	query := "SELECT id, attachment_id, size, width, height, content_type, storage_key, created_at, updated_at " +
+		"FROM attachment_thumbnails WHERE attachment_id = ? AND size = ?"
+
+	err := r.db.QueryRow(query, attachmentID, size).Scan(
+		&thumbnail.ID, &thumbnail.AttachmentID, &thumbnail.Size, &thumbnail.Width, &thumbnail.Height,
+		&thumbnail.ContentType, &thumbnail.StorageKey, &thumbnail.CreatedAt, &thumbnail.UpdatedAt,
+	)
+	if err != nil {
+		return thumbnail, err
+	}
+
+	return thumbnail, nil
+}
+
+// SaveThumbnail stores the thumbnail, replacing one of the same size made by
+// an earlier attempt.
+func (r *repository) SaveThumbnail(thumbnail Thumbnail) error {
+	query := "INSERT INTO attachment_thumbnails SET " +
+		"attachment_id = ?, size = ?, width = ?, height = ?, content_type = ?, storage_key = ?, created_at = NOW(), updated_at = NOW() " +
+		"ON DUPLICATE KEY UPDATE width = VALUES(width), height = VALUES(height), content_type = VALUES(content_type), " +
+		"storage_key = VALUES(storage_key), updated_at = NOW()"
+
+	_, err := r.db.Exec(query,
+		thumbnail.AttachmentID, thumbnail.Size, thumbnail.Width, thumbnail.Height, thumbnail.ContentType, thumbnail.StorageKey,
+	)
+	return err
+}
@@ -0,0 +1,490 @@
+package attachment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+	"github.com/iqbaleff214/easynote-backend-go/storage"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
+)
+
+const (
+	// batchSize is how many attachments a sweep loads at a time
+	batchSize = 100
+	// maxConcurrentThumbnails caps how many images are processed at once
+	maxConcurrentThumbnails = 2
+)
+
+type Service interface {
+	FindAttachments(userID, noteID int) ([]Attachment, error)
+	UploadAttachment(userID, noteID int, filename string, body io.Reader, size int64) (Attachment, error)
+	OpenAttachment(userID, noteID, attachmentID int) (Attachment, io.ReadCloser, error)
+	OpenThumbnail(userID, noteID, attachmentID int, size string) (Thumbnail, io.ReadCloser, error)
+	DeleteAttachment(userID, noteID, attachmentID int) error
+	FindUsage(userID int) (Usage, error)
+	PurgeOrphanedAttachments() error
+	ProcessPendingThumbnails() error
+}
+
+type service struct {
+	repository   Repository
+	transactions transaction.Manager
+	noteService  note.Service
+	store        storage.BlobStore
+	limits       Limits
+	slots        chan struct{}
+}
+
+func NewService(repository Repository, transactions transaction.Manager, noteService note.Service, store storage.BlobStore, limits Limits) *service {
+	return &service{repository, transactions, noteService, store, limits, make(chan struct{}, maxConcurrentThumbnails)}
+}
+
+// FindAttachments lists the note's attachments to anyone who can view it.
+func (s *service) FindAttachments(userID, noteID int) ([]Attachment, error) {
+	if _, err := s.noteService.FindNote(userID, noteID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.repository.FindByNoteID(noteID)
+	if err != nil || len(attachments) == 0 {
+		return attachments, err
+	}
+
+	var attachmentIDs []int
+	for _, attachment := range attachments {
+		attachmentIDs = append(attachmentIDs, attachment.ID)
+	}
+
+	thumbnails, err := s.repository.FindThumbnails(attachmentIDs)
+	if err != nil {
+		return attachments, err
+	}
+
+	mappedThumbnails := map[int][]Thumbnail{}
+	for _, thumbnail := range thumbnails {
+		mappedThumbnails[thumbnail.AttachmentID] = append(mappedThumbnails[thumbnail.AttachmentID], thumbnail)
+	}
+
+	for i, attachment := range attachments {
+		attachments[i].Thumbnails = mappedThumbnails[attachment.ID]
+	}
+
+	return attachments, nil
+}
+
+// UploadAttachment stores the file and attaches it to the note, which takes
+// editor access. The file counts against the quota of the note's owner, who
+// keeps it whoever uploaded it. Images lose their metadata before they're
+// stored, and get their thumbnails made in the background.
+func (s *service) UploadAttachment(userID, noteID int, filename string, body io.Reader, size int64) (Attachment, error) {
+	var attachment Attachment
+
+	attachedNote, err := s.editableNote(userID, noteID)
+	if err != nil {
+		return attachment, err
+	}
+
+	if size <= 0 {
+		return attachment, ErrEmptyFile
+	}
+
+	if s.limits.MaxSize > 0 && size > s.limits.MaxSize {
+		return attachment, ErrTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return attachment, err
+	}
+	head = head[:n]
+
+	attachment.NoteID = noteID
+	attachment.UserID = attachedNote.UserID
+	attachment.Filename = cleanFilename(filename)
+	attachment.ContentType = detectType(head, attachment.Filename)
+	attachment.Size = size
+
+	if !s.limits.allowsType(attachment.ContentType) {
+		return attachment, ErrTypeNotAllowed
+	}
+
+	if isThumbnailable(attachment.ContentType) {
+		data, err := io.ReadAll(io.LimitReader(io.MultiReader(bytes.NewReader(head), body), size))
+		if err != nil {
+			return attachment, err
+		}
+
+		data, err = stripMetadata(attachment.ContentType, data)
+		if err != nil {
+			return attachment, ErrInvalidImage
+		}
+
+		head, body = data, bytes.NewReader(nil)
+		attachment.Size = int64(len(data))
+		attachment.ThumbnailStatus = ThumbnailPending
+	}
+
+	// Checked once up front to avoid storing a file that can't fit, and again
+	// below under the lock, which is what actually holds the quota
+	if err := s.checkQuota(s.repository, attachment); err != nil {
+		return attachment, err
+	}
+
+	attachment.StorageKey, err = newStorageKey(attachment.UserID)
+	if err != nil {
+		return attachment, err
+	}
+
+	if err := s.store.Put(attachment.StorageKey, io.MultiReader(bytes.NewReader(head), body), attachment.Size, attachment.ContentType); err != nil {
+		return attachment, err
+	}
+
+	err = s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		if err := repository.LockUser(attachment.UserID); err != nil {
+			return err
+		}
+
+		if err := s.checkQuota(repository, attachment); err != nil {
+			return err
+		}
+
+		attachment, err = repository.Save(attachment)
+		return err
+	})
+	if err != nil {
+		s.deleteBlob(attachment.StorageKey)
+		return attachment, err
+	}
+
+	if attachment.ThumbnailStatus == ThumbnailPending {
+		go s.makeThumbnails(attachment)
+	}
+
+	return attachment, nil
+}
+
+func (s *service) checkQuota(repository Repository, attachment Attachment) error {
+	if s.limits.Quota <= 0 {
+		return nil
+	}
+
+	used, err := repository.SumSizeByUserID(attachment.UserID)
+	if err != nil {
+		return err
+	}
+
+	if used+attachment.Size > s.limits.Quota {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// OpenAttachment returns the attachment along with its contents, which the
+// caller has to close.
+func (s *service) OpenAttachment(userID, noteID, attachmentID int) (Attachment, io.ReadCloser, error) {
+	if _, err := s.noteService.FindNote(userID, noteID); err != nil {
+		return Attachment{}, nil, err
+	}
+
+	attachment, err := s.repository.FindByID(noteID, attachmentID)
+	if err != nil {
+		return attachment, nil, helper.NoRows(err, ErrNotFound)
+	}
+
+	contents, err := s.store.Get(attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return attachment, nil, ErrNotFound
+	}
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	return attachment, contents, nil
+}
+
+// OpenThumbnail returns one of the image's thumbnails along with its
+// contents, which the caller has to close.
+func (s *service) OpenThumbnail(userID, noteID, attachmentID int, size string) (Thumbnail, io.ReadCloser, error) {
+	if !IsValidThumbnailSize(size) {
+		return Thumbnail{}, nil, ErrThumbnailNotFound
+	}
+
+	if _, err := s.noteService.FindNote(userID, noteID); err != nil {
+		return Thumbnail{}, nil, err
+	}
+
+	attachment, err := s.repository.FindByID(noteID, attachmentID)
+	if err != nil {
+		return Thumbnail{}, nil, helper.NoRows(err, ErrNotFound)
+	}
+
+	thumbnail, err := s.repository.FindThumbnail(attachment.ID, size)
+	if err != nil {
+		return thumbnail, nil, helper.NoRows(err, ErrThumbnailNotFound)
+	}
+
+	contents, err := s.store.Get(thumbnail.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return thumbnail, nil, ErrThumbnailNotFound
+	}
+	if err != nil {
+		return thumbnail, nil, err
+	}
+
+	return thumbnail, contents, nil
+}
+
+func (s *service) DeleteAttachment(userID, noteID, attachmentID int) error {
+	if _, err := s.editableNote(userID, noteID); err != nil {
+		return err
+	}
+
+	attachment, err := s.repository.FindByID(noteID, attachmentID)
+	if err != nil {
+		return helper.NoRows(err, ErrNotFound)
+	}
+
+	// The row goes first, a blob left behind by a failure is only wasted space
+	if err := s.repository.Delete(attachment); err != nil {
+		return err
+	}
+
+	for _, key := range blobKeys(attachment) {
+		s.deleteBlob(key)
+	}
+
+	return nil
+}
+
+func (s *service) FindUsage(userID int) (Usage, error) {
+	usage := Usage{Quota: s.limits.Quota, MaxSize: s.limits.MaxSize}
+
+	if userID == 0 {
+		return usage, helper.ErrNoSession
+	}
+
+	used, err := s.repository.SumSizeByUserID(userID)
+	if err != nil {
+		return usage, err
+	}
+	usage.Used = used
+
+	return usage, nil
+}
+
+// PurgeOrphanedAttachments deletes the blobs of attachments whose note was
+// purged, then the attachments themselves.
+func (s *service) PurgeOrphanedAttachments() error {
+	for {
+		attachments, err := s.repository.FindOrphaned(batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			for _, key := range blobKeys(attachment) {
+				if err := s.store.Delete(key); err != nil {
+					return err
+				}
+			}
+
+			if err := s.repository.Delete(attachment); err != nil {
+				return err
+			}
+		}
+
+		if len(attachments) < batchSize {
+			return nil
+		}
+	}
+}
+
+// ProcessPendingThumbnails makes the thumbnails a restart left unfinished.
+func (s *service) ProcessPendingThumbnails() error {
+	afterID := 0
+
+	for {
+		attachments, err := s.repository.FindPendingThumbnails(afterID, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			s.makeThumbnails(attachment)
+			afterID = attachment.ID
+		}
+
+		if len(attachments) < batchSize {
+			return nil
+		}
+	}
+}
+
+// makeThumbnails records the image's dimensions and stores a thumbnail in
+// every size, marking the attachment ready or, when the image can't be
+// read, failed.
+func (s *service) makeThumbnails(attachment Attachment) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("attachment %d: thumbnails: %v", attachment.ID, recovered)
+			attachment.ThumbnailStatus = ThumbnailFailed
+			s.updateImage(attachment)
+		}
+	}()
+
+	attachment.ThumbnailStatus = ThumbnailReady
+	if err := s.writeThumbnails(&attachment); err != nil {
+		log.Printf("attachment %d: thumbnails: %v", attachment.ID, err)
+		attachment.ThumbnailStatus = ThumbnailFailed
+	}
+
+	s.updateImage(attachment)
+}
+
+func (s *service) writeThumbnails(attachment *Attachment) error {
+	contents, err := s.store.Get(attachment.StorageKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(contents)
+	contents.Close()
+	if err != nil {
+		return err
+	}
+
+	img, orientation, err := decodeImage(attachment.ContentType, data)
+	if err != nil {
+		return err
+	}
+
+	attachment.Width, attachment.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	if orientation >= 5 {
+		attachment.Width, attachment.Height = attachment.Height, attachment.Width
+	}
+
+	for _, size := range ThumbnailSizes {
+		resized := orient(resize(img, size.MaxSide), orientation)
+
+		encoded, contentType, err := encodeThumbnail(attachment.ContentType, resized)
+		if err != nil {
+			return err
+		}
+
+		thumbnail := Thumbnail{
+			AttachmentID: attachment.ID,
+			Size:         size.Name,
+			Width:        resized.Bounds().Dx(),
+			Height:       resized.Bounds().Dy(),
+			ContentType:  contentType,
+			StorageKey:   thumbnailKey(attachment.StorageKey, size.Name),
+		}
+
+		if err := s.store.Put(thumbnail.StorageKey, bytes.NewReader(encoded), int64(len(encoded)), contentType); err != nil {
+			return err
+		}
+
+		if err := s.repository.SaveThumbnail(thumbnail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) updateImage(attachment Attachment) {
+	if err := s.repository.UpdateImage(attachment); err != nil {
+		log.Printf("attachment %d: thumbnails: %v", attachment.ID, err)
+	}
+}
+
+// blobKeys lists the attachment's file and, for images, its thumbnails.
+func blobKeys(attachment Attachment) []string {
+	keys := []string{attachment.StorageKey}
+
+	if attachment.ThumbnailStatus != "" {
+		for _, size := range ThumbnailSizes {
+			keys = append(keys, thumbnailKey(attachment.StorageKey, size.Name))
+		}
+	}
+
+	return keys
+}
+
+func (s *service) editableNote(userID, noteID int) (note.Note, error) {
+	editable, err := s.noteService.FindNote(userID, noteID)
+	if err != nil {
+		return editable, err
+	}
+
+	if !note.Allows(editable.Permission, note.PermissionEditor) {
+		return editable, note.ErrForbidden
+	}
+
+	return editable, nil
+}
+
+func (s *service) deleteBlob(key string) {
+	if err := s.store.Delete(key); err != nil {
+		log.Printf("attachment: deleting blob %s: %v", key, err)
+	}
+}
+
+// newStorageKey returns a random key under the owner's prefix. The original
+// filename stays in the database only, so it never has to be escaped.
+func newStorageKey(userID int) (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("attachments/%d/%s", userID, hex.EncodeToString(bytes)), nil
+}
+
+// cleanFilename keeps the base name of what the client sent, without control
+// characters and within the column's 255 characters.
+func cleanFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, filename)
+	filename = strings.TrimSpace(filename)
+
+	if filename == "" || filename == "." || filename == "/" {
+		return "file"
+	}
+
+	if utf8.RuneCountInString(filename) > 255 {
+		ext := path.Ext(filename)
+		if utf8.RuneCountInString(ext) > 16 {
+			ext = ""
+		}
+
+		filename = string([]rune(filename)[:255-utf8.RuneCountInString(ext)]) + ext
+	}
+
+	return filename
+}
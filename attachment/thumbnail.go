@@ -0,0 +1,210 @@
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	ThumbnailPending = "pending"
+	ThumbnailReady   = "ready"
+	ThumbnailFailed  = "failed"
+)
+
+// maxImagePixels keeps a small file that decodes to a huge image from
+// taking all the memory.
+const maxImagePixels = 50_000_000
+
+var errImageTooLarge = errors.New("image has too many pixels")
+
+// ThumbnailSize fits a thumbnail within MaxSide pixels on either side.
+type ThumbnailSize struct {
+	Name    string
+	MaxSide int
+}
+
+var ThumbnailSizes = []ThumbnailSize{
+	{"small", 128},
+	{"medium", 512},
+	{"large", 1024},
+}
+
+func IsValidThumbnailSize(name string) bool {
+	for _, size := range ThumbnailSizes {
+		if size.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isThumbnailable tells whether the type is an image thumbnails can be made
+// of.
+func isThumbnailable(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+
+	return false
+}
+
+func thumbnailKey(storageKey, size string) string {
+	return storageKey + "-" + size
+}
+
+// decodeImage decodes the image along with the EXIF orientation it should be
+// shown in, which only JPEGs carry. Turning a thumbnail is far cheaper than
+// turning the original, so that's left to the caller.
+func decodeImage(contentType string, data []byte) (image.Image, int, error) {
+	decodeConfig, decode := png.DecodeConfig, png.Decode
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case "image/gif":
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	case "image/webp":
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if config.Width*config.Height > maxImagePixels {
+		return nil, 0, errImageTooLarge
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	return img, orientation, nil
+}
+
+// jpegOrientation finds the orientation left in a stripped JPEG.
+func jpegOrientation(data []byte) int {
+	for offset := 2; offset+4 <= len(data) && data[offset] == 0xFF; {
+		marker := data[offset+1]
+		if marker == 0xDA {
+			break
+		}
+
+		end := offset + 2 + (int(data[offset+2])<<8 | int(data[offset+3]))
+		if end > len(data) {
+			break
+		}
+
+		if marker == 0xE1 {
+			if orientation := exifOrientation(data[offset+4 : end]); orientation != 0 {
+				return orientation
+			}
+		}
+
+		offset = end
+	}
+
+	return 1
+}
+
+// orient turns the image as its EXIF orientation says it was taken. Values 5
+// to 8 swap width and height.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	outW, outH := w, h
+	if orientation >= 5 {
+		outW, outH = h, w
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outW, outH))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+
+			out.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return out
+}
+
+// resize scales the image down to fit within maxSide, never up.
+func resize(img image.Image, maxSide int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	if w <= maxSide && h <= maxSide {
+		return img
+	}
+
+	if w >= h {
+		h = max(h*maxSide/w, 1)
+		w = maxSide
+	} else {
+		w = max(w*maxSide/h, 1)
+		h = maxSide
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(out, out.Bounds(), img, bounds, draw.Src, nil)
+
+	return out
+}
+
+// encodeThumbnail keeps photos as JPEG and writes everything else as PNG,
+// which keeps transparency. Either way the thumbnail carries no metadata.
+func encodeThumbnail(contentType string, img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+			return nil, "", err
+		}
+
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), "image/png", nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one signing key, identified by the kid header of the tokens it signs.
+// Keys loaded from a public key only verify tokens; they can't sign new ones.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	SigningKey any
+	VerifyKey  any
+}
+
+func (k Key) CanSign() bool {
+	return k.SigningKey != nil
+}
+
+// NewHMACKey wraps a shared secret as an HS256 key. HMAC keys are secret, so
+// they're never published in the JWKS.
+func NewHMACKey(id, secret string) Key {
+	return Key{
+		ID:         id,
+		Method:     jwt.SigningMethodHS256,
+		SigningKey: []byte(secret),
+		VerifyKey:  []byte(secret),
+	}
+}
+
+// LoadKey reads an RSA or Ed25519 key from a PEM file. Its kid is the file
+// name without extension, so keys/2024-02.pem signs tokens with kid 2024-02.
+func LoadKey(path string) (Key, error) {
+	var key Key
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return key, err
+	}
+
+	block, _ := pem.Decode(bytes)
+	if block == nil {
+		return key, errors.New("no PEM data found in " + path)
+	}
+
+	key.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	parsed, err := parsePEMBlock(block)
+	if err != nil {
+		return key, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.SigningKey = k
+		key.VerifyKey = &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+		key.VerifyKey = k
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.SigningKey = k
+		key.VerifyKey = k.Public()
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.VerifyKey = k
+	default:
+		return key, errors.New("unsupported key type in " + path + ", use RSA or Ed25519")
+	}
+
+	return key, nil
+}
+
+func parsePEMBlock(block *pem.Block) (any, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	return nil, errors.New("unsupported PEM block " + block.Type)
+}
@@ -1,35 +1,131 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+type Claims struct {
+	UserID    int `json:"user_id"`
+	SessionID int `json:"session_id"`
+	jwt.RegisteredClaims
+}
+
 type Service interface {
-	GenerateToken(userID int) (string, error)
+	GenerateToken(userID, sessionID int) (string, error)
+	ParseToken(token string) (Claims, error)
+	JWKS() JWKS
 }
 
 type service struct {
-	jwtSecret string
+	keys     []Key
+	issuer   string
+	audience string
+	tokenTTL time.Duration
 }
 
-func NewService(jwtSecret string) *service {
-	return &service{jwtSecret}
+// NewService signs tokens with the first key and accepts tokens signed by any
+// of them, so a retired key can stay listed until its tokens have expired.
+func NewService(keys []Key, issuer, audience string, tokenTTL time.Duration) (*service, error) {
+	if len(keys) == 0 || !keys[0].CanSign() {
+		return nil, errors.New("the first key must be able to sign tokens")
+	}
+
+	return &service{keys, issuer, audience, tokenTTL}, nil
 }
 
-func (s *service) GenerateToken(userID int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":    userID,
-		"expired_at": time.Now().Add(time.Hour * 24 * 100).Format(time.RFC822),
+func (s *service) GenerateToken(userID, sessionID int) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.issuer,
+			Subject:   strconv.Itoa(userID),
+			Audience:  jwt.ClaimStrings{s.audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenTTL)),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key := s.keys[0]
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
 
-	signedToken, err := token.SignedString([]byte(s.jwtSecret))
+	signedToken, err := token.SignedString(key.SigningKey)
 	if err != nil {
 		return signedToken, err
 	}
 
 	return signedToken, nil
 }
+
+func (s *service) ParseToken(tokenString string) (Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(tokenString, &claims, s.verifyKey,
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return claims, err
+	}
+
+	return claims, nil
+}
+
+// verifyKey picks the key named by the token's kid, and only accepts the
+// token when it was signed with that key's algorithm.
+func (s *service) verifyKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	for _, key := range s.keys {
+		if key.ID != kid {
+			continue
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		return key.VerifyKey, nil
+	}
+
+	return nil, errors.New("unknown signing key")
+}
+
+func (s *service) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range s.keys {
+		if jwk, ok := formatJWK(key); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
+
+func newTokenID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
@@ -1,11 +1,61 @@
 package main
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/attachment"
+	"github.com/iqbaleff214/easynote-backend-go/auth"
+	"github.com/iqbaleff214/easynote-backend-go/handler"
+	"github.com/iqbaleff214/easynote-backend-go/mail"
+	"github.com/iqbaleff214/easynote-backend-go/storage"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
 
 type config struct {
-	mysqlUri  string
-	jwtSecret string
-	version   string
+	mysqlUri       string
+	jwtSecret      string
+	jwtKeys        []string
+	jwtIssuer      string
+	jwtAudience    string
+	version        string
+	trashRetention time.Duration
+	tokenTTL       time.Duration
+	refreshTTL     time.Duration
+	appURL         string
+	mailDriver     string
+	mailFrom       string
+	mailLogDir     string
+	smtpHost       string
+	smtpPort       int
+	smtpUsername   string
+	smtpPassword   string
+	proxyHeader    string
+	trustedProxies []string
+	loginPolicy    user.LoginPolicy
+	rateLimits     rateLimits
+	importMaxSize  int
+	storageDriver  string
+	storageDir     string
+	s3Endpoint     string
+	s3Region       string
+	s3Bucket       string
+	s3AccessKey    string
+	s3SecretKey    string
+	attachments    attachment.Limits
+}
+
+type rateLimits struct {
+	login        handler.RateLimit
+	loginAccount handler.RateLimit
+	twoFactor    handler.RateLimit
+	register     handler.RateLimit
+	password     handler.RateLimit
+	search       handler.RateLimit
+	shareLink    handler.RateLimit
+	importing    handler.RateLimit
 }
 
 var appConfig config
@@ -20,11 +70,248 @@ func initConfig() {
 	if jwtSecret == "" {
 		jwtSecret = "easynotejwtsecret123"
 	}
-	
+
+	// Comma separated PEM files, the first one signs and the rest only verify
+	var jwtKeys []string
+	for _, path := range strings.Split(os.Getenv("JWT_KEYS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			jwtKeys = append(jwtKeys, path)
+		}
+	}
+
+	jwtIssuer := os.Getenv("JWT_ISSUER")
+	if jwtIssuer == "" {
+		jwtIssuer = "easynote"
+	}
+
+	jwtAudience := os.Getenv("JWT_AUDIENCE")
+	if jwtAudience == "" {
+		jwtAudience = "easynote"
+	}
+
 	version := os.Getenv("VERSION")
 	if version == "" {
 		version = "1"
 	}
 
-	appConfig = config{mysqlUri, jwtSecret, version}
+	trashRetentionDays, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || trashRetentionDays <= 0 {
+		trashRetentionDays = 30
+	}
+	trashRetention := time.Hour * 24 * time.Duration(trashRetentionDays)
+
+	tokenTTLMinutes, err := strconv.Atoi(os.Getenv("TOKEN_TTL_MINUTES"))
+	if err != nil || tokenTTLMinutes <= 0 {
+		tokenTTLMinutes = 15
+	}
+	tokenTTL := time.Minute * time.Duration(tokenTTLMinutes)
+
+	refreshTTLDays, err := strconv.Atoi(os.Getenv("REFRESH_TOKEN_TTL_DAYS"))
+	if err != nil || refreshTTLDays <= 0 {
+		refreshTTLDays = 30
+	}
+	refreshTTL := time.Hour * 24 * time.Duration(refreshTTLDays)
+
+	// Used to build the links sent by email, so it points at the frontend
+	appURL := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if appURL == "" {
+		appURL = "http://localhost:3000"
+	}
+
+	mailDriver := os.Getenv("MAIL_DRIVER")
+	if mailDriver == "" {
+		mailDriver = "log"
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "EasyNote <no-reply@easynote.local>"
+	}
+
+	mailLogDir := os.Getenv("MAIL_LOG_DIR")
+	smtpHost := os.Getenv("SMTP_HOST")
+
+	smtpPort, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || smtpPort <= 0 {
+		smtpPort = 587
+	}
+
+	smtpUsername := os.Getenv("SMTP_USERNAME")
+	smtpPassword := os.Getenv("SMTP_PASSWORD")
+
+	// Set to e.g. X-Forwarded-For behind a reverse proxy, otherwise every
+	// client shares the proxy's IP and rate limits
+	proxyHeader := os.Getenv("PROXY_HEADER")
+
+	// Comma separated IPs or CIDR ranges of the proxies allowed to set the
+	// header. Without them anyone could pick their own IP, so it's ignored
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if len(trustedProxies) == 0 {
+		proxyHeader = ""
+	}
+
+	loginMaxAttempts, err := strconv.Atoi(os.Getenv("LOGIN_MAX_ATTEMPTS"))
+	if err != nil || loginMaxAttempts < 0 {
+		loginMaxAttempts = 5
+	}
+	loginPolicy := user.LoginPolicy{
+		MaxAttempts: loginMaxAttempts,
+		Lockout:     envDuration("LOGIN_LOCKOUT", time.Minute),
+		MaxLockout:  envDuration("LOGIN_MAX_LOCKOUT", time.Hour),
+	}
+
+	// Written as requests/window, e.g. RATE_LIMIT_LOGIN=10/1m
+	limits := rateLimits{
+		login:        envRateLimit("RATE_LIMIT_LOGIN", handler.RateLimit{Max: 10, Window: time.Minute}),
+		loginAccount: envRateLimit("RATE_LIMIT_LOGIN_ACCOUNT", handler.RateLimit{Max: 5, Window: time.Minute}),
+		twoFactor:    envRateLimit("RATE_LIMIT_TWO_FACTOR", handler.RateLimit{Max: 5, Window: time.Minute * 5}),
+		register:     envRateLimit("RATE_LIMIT_REGISTER", handler.RateLimit{Max: 5, Window: time.Hour}),
+		password:     envRateLimit("RATE_LIMIT_PASSWORD", handler.RateLimit{Max: 5, Window: time.Minute * 10}),
+		search:       envRateLimit("RATE_LIMIT_SEARCH", handler.RateLimit{Max: 60, Window: time.Minute}),
+		shareLink:    envRateLimit("RATE_LIMIT_SHARE_LINK", handler.RateLimit{Max: 10, Window: time.Minute * 10}),
+		importing:    envRateLimit("RATE_LIMIT_IMPORT", handler.RateLimit{Max: 10, Window: time.Hour}),
+	}
+	limits.shareLink.FailedOnly = true
+
+	// Uploads are read into memory whole, so this also bounds an import's
+	// memory use
+	importMaxMB, err := strconv.Atoi(os.Getenv("IMPORT_MAX_MB"))
+	if err != nil || importMaxMB <= 0 {
+		importMaxMB = 32
+	}
+	importMaxSize := importMaxMB << 20
+
+	storageDriver := os.Getenv("STORAGE_DRIVER")
+	if storageDriver == "" {
+		storageDriver = "local"
+	}
+
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "uploads"
+	}
+
+	// Any S3-compatible service, e.g. https://s3.eu-west-1.amazonaws.com or
+	// a local MinIO at http://127.0.0.1:9000
+	s3Endpoint := os.Getenv("S3_ENDPOINT")
+	s3Region := os.Getenv("S3_REGION")
+	s3Bucket := os.Getenv("S3_BUCKET")
+	s3AccessKey := os.Getenv("S3_ACCESS_KEY")
+	s3SecretKey := os.Getenv("S3_SECRET_KEY")
+
+	// Sizes are in MB, 0 turns the limit off
+	attachmentMaxMB, err := strconv.Atoi(os.Getenv("ATTACHMENT_MAX_MB"))
+	if err != nil || attachmentMaxMB < 0 {
+		attachmentMaxMB = 10
+	}
+
+	storageQuotaMB, err := strconv.Atoi(os.Getenv("STORAGE_QUOTA_MB"))
+	if err != nil || storageQuotaMB < 0 {
+		storageQuotaMB = 500
+	}
+
+	// Comma separated MIME types, type/* allows a whole family and * any type
+	attachmentTypes := []string{"image/*", "application/pdf", "text/plain", "text/markdown"}
+	if types := os.Getenv("ATTACHMENT_TYPES"); types != "" {
+		attachmentTypes = nil
+		for _, contentType := range strings.Split(types, ",") {
+			if contentType = strings.TrimSpace(contentType); contentType == "*" {
+				attachmentTypes = nil
+				break
+			} else if contentType != "" {
+				attachmentTypes = append(attachmentTypes, contentType)
+			}
+		}
+	}
+
+	attachments := attachment.Limits{
+		MaxSize:      int64(attachmentMaxMB) << 20,
+		Quota:        int64(storageQuotaMB) << 20,
+		AllowedTypes: attachmentTypes,
+	}
+
+	appConfig = config{
+		mysqlUri, jwtSecret, jwtKeys, jwtIssuer, jwtAudience, version, trashRetention, tokenTTL, refreshTTL,
+		appURL, mailDriver, mailFrom, mailLogDir, smtpHost, smtpPort, smtpUsername, smtpPassword,
+		proxyHeader, trustedProxies, loginPolicy, limits, importMaxSize,
+		storageDriver, storageDir, s3Endpoint, s3Region, s3Bucket, s3AccessKey, s3SecretKey, attachments,
+	}
+}
+
+// signingKeys loads the keys listed in JWT_KEYS, falling back to signing with
+// JWT_SECRET when none are configured.
+func signingKeys() ([]auth.Key, error) {
+	if len(appConfig.jwtKeys) == 0 {
+		return []auth.Key{auth.NewHMACKey("default", appConfig.jwtSecret)}, nil
+	}
+
+	var keys []auth.Key
+
+	for _, path := range appConfig.jwtKeys {
+		key, err := auth.LoadKey(path)
+		if err != nil {
+			return keys, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func mailer() mail.Mailer {
+	if appConfig.mailDriver == "smtp" {
+		return mail.NewSMTPMailer(appConfig.smtpHost, appConfig.smtpPort, appConfig.smtpUsername, appConfig.smtpPassword, appConfig.mailFrom)
+	}
+
+	return mail.NewLogMailer(appConfig.mailLogDir, appConfig.mailFrom)
+}
+
+// bodyLimit lets through the largest upload allowed, with room for the
+// multipart encoding around it.
+func bodyLimit() int {
+	return max(appConfig.importMaxSize, int(appConfig.attachments.MaxSize)) + 1<<20
+}
+
+func blobStore() (storage.BlobStore, error) {
+	if appConfig.storageDriver == "s3" {
+		return storage.NewS3Store(appConfig.s3Endpoint, appConfig.s3Region, appConfig.s3Bucket, appConfig.s3AccessKey, appConfig.s3SecretKey)
+	}
+
+	return storage.NewLocalStore(appConfig.storageDir)
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+
+	return duration
+}
+
+// envRateLimit reads a limit written as requests/window, such as 10/1m. A
+// limit of 0 requests turns it off.
+func envRateLimit(name string, fallback handler.RateLimit) handler.RateLimit {
+	max, window, found := strings.Cut(os.Getenv(name), "/")
+	if !found {
+		return fallback
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(max))
+	if err != nil || limit < 0 {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+
+	return handler.RateLimit{Max: limit, Window: duration}
 }
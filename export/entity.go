@@ -0,0 +1,50 @@
+package export
+
+import (
+	"io"
+
+	"github.com/iqbaleff214/easynote-backend-go/folder"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+// Archive is everything exported for a user, ready to be written out. Notes
+// are only fetched while it's written, a page at a time.
+type Archive struct {
+	Format  string
+	Folders []folder.Folder
+	Notes   NotePages
+}
+
+// NotePages returns the page of notes following the one with afterID, tags
+// included, and no notes once there are none left.
+type NotePages func(afterID int) ([]note.Note, error)
+
+// Write writes the archive out in its format.
+func (a Archive) Write(w io.Writer) error {
+	if a.Format == FormatJSON {
+		return a.WriteJSON(w)
+	}
+
+	return a.WriteZip(w)
+}
+
+// eachNote calls fn with every exported note in id order, fetching the next
+// page once it's done with the previous one.
+func (a Archive) eachNote(fn func(n note.Note) error) error {
+	afterID := 0
+
+	for {
+		notes, err := a.Notes(afterID)
+		if err != nil || len(notes) == 0 {
+			return err
+		}
+
+		for _, n := range notes {
+			if err := fn(n); err != nil {
+				return err
+			}
+		}
+
+		afterID = notes[len(notes)-1].ID
+	}
+}
@@ -0,0 +1,5 @@
+package export
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var ErrUnsupportedFormat = helper.NewError(helper.ErrValidation, "unsupported_export_format", "format must be zip or json")
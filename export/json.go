@@ -0,0 +1,128 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+const DocumentVersion = 1
+
+// Document is the JSON form of an export, which the importer reads back.
+// Notes come last, so a writer can stream them.
+// Folders refer to their parent, and notes to their folder, by the folder's
+// id in the document.
+type Document struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Folders    []DocumentFolder `json:"folders"`
+	Notes      []DocumentNote   `json:"notes"`
+}
+
+type DocumentFolder struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id,omitempty"`
+}
+
+type DocumentNote struct {
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Format    string    `json:"format"`
+	Tags      []string  `json:"tags"`
+	IsPublic  bool      `json:"is_public"`
+	FolderID  int       `json:"folder_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// documentFolders returns the exported folders, and which ids they have, so
+// notes only refer to folders that are in the document.
+func (a Archive) documentFolders() ([]DocumentFolder, map[int]bool) {
+	documentFolders := []DocumentFolder{}
+
+	exported := map[int]bool{}
+	for _, f := range a.Folders {
+		exported[f.ID] = true
+	}
+
+	for _, f := range a.Folders {
+		documentFolder := DocumentFolder{ID: f.ID, Name: f.Name}
+		if exported[f.ParentID] {
+			documentFolder.ParentID = f.ParentID
+		}
+
+		documentFolders = append(documentFolders, documentFolder)
+	}
+
+	return documentFolders, exported
+}
+
+func documentNote(n note.Note, exported map[int]bool) DocumentNote {
+	tags := []string{}
+	for _, tag := range n.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	documentNote := DocumentNote{
+		Title:     n.Title,
+		Content:   n.Content,
+		Format:    n.Format,
+		Tags:      tags,
+		IsPublic:  n.IsPublic,
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
+	}
+	if exported[n.FolderID] {
+		documentNote.FolderID = n.FolderID
+	}
+
+	return documentNote
+}
+
+// WriteJSON writes the archive as a single JSON document. The notes are
+// encoded one at a time as they're fetched, instead of building the whole
+// Document first.
+func (a Archive) WriteJSON(w io.Writer) error {
+	folders, exported := a.documentFolders()
+
+	head, err := json.Marshal(Document{
+		Version:    DocumentVersion,
+		ExportedAt: time.Now().UTC(),
+		Folders:    folders,
+		Notes:      []DocumentNote{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// The head ends with the empty notes array, "[]}", which is reopened to
+	// write the notes into
+	if _, err := w.Write(head[:len(head)-2]); err != nil {
+		return err
+	}
+
+	separator := ""
+	err = a.eachNote(func(n note.Note) error {
+		encoded, err := json.Marshal(documentNote(n, exported))
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(w, separator); err != nil {
+			return err
+		}
+		separator = ","
+
+		_, err = w.Write(encoded)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"github.com/iqbaleff214/easynote-backend-go/folder"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+const (
+	FormatZip  = "zip"
+	FormatJSON = "json"
+)
+
+// notesPerPage is how many notes, and their tags, an export fetches at once.
+const notesPerPage = 200
+
+type Service interface {
+	ExportNotes(userID int, format string) (Archive, error)
+}
+
+type service struct {
+	noteRepository   note.Repository
+	folderRepository folder.Repository
+}
+
+func NewService(noteRepository note.Repository, folderRepository folder.Repository) *service {
+	return &service{noteRepository, folderRepository}
+}
+
+// ExportNotes gathers the user's folders and hands out their notes a page at
+// a time, tags included. Trashed ones are left out.
+func (s *service) ExportNotes(userID int, format string) (Archive, error) {
+	var archive Archive
+
+	if userID == 0 {
+		return archive, helper.ErrNoSession
+	}
+
+	switch format {
+	case "":
+		format = FormatZip
+	case FormatZip, FormatJSON:
+	default:
+		return archive, ErrUnsupportedFormat
+	}
+
+	folders, err := s.folderRepository.FindTreeByUserID(userID)
+	if err != nil {
+		return archive, err
+	}
+
+	archive.Format = format
+	archive.Folders = folders
+	archive.Notes = func(afterID int) ([]note.Note, error) {
+		return s.findNotes(userID, afterID)
+	}
+
+	return archive, nil
+}
+
+func (s *service) findNotes(userID, afterID int) ([]note.Note, error) {
+	notes, err := s.noteRepository.FindAllByUserID(userID, afterID, notesPerPage)
+	if err != nil || len(notes) == 0 {
+		return notes, err
+	}
+
+	var noteIDs []int
+	for _, n := range notes {
+		noteIDs = append(noteIDs, n.ID)
+	}
+
+	tags, err := s.noteRepository.FindTagsByNoteIDs(noteIDs)
+	if err != nil {
+		return notes, err
+	}
+
+	mappedTags := map[int][]note.Tag{}
+	for _, tag := range tags {
+		mappedTags[tag.NoteID] = append(mappedTags[tag.NoteID], tag)
+	}
+
+	for i, n := range notes {
+		notes[i].Tags = mappedTags[n.ID]
+	}
+
+	return notes, nil
+}
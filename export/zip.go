@@ -0,0 +1,161 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/folder"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+// WriteZip writes the archive as a zip with a directory per folder, nested
+// like the folders are, and a Markdown file per note.
+func (a Archive) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	dirs := folderDirs(a.Folders)
+
+	// Empty folders are kept as directory entries
+	for _, f := range a.Folders {
+		if _, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     dirs[f.ID] + "/",
+			Modified: f.UpdatedAt,
+		}); err != nil {
+			return err
+		}
+	}
+
+	taken := map[string]bool{}
+
+	err := a.eachNote(func(n note.Note) error {
+		name := uniqueName(path.Join(dirs[n.FolderID], safeName(n.Title, "Untitled")), ".md", taken)
+
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: n.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(file, markdownFile(n, dirs[n.FolderID]))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// folderDirs maps each folder to its path in the archive. Notes outside of a
+// folder, or in one that isn't exported, end up at the root.
+func folderDirs(folders []folder.Folder) map[int]string {
+	byID := map[int]folder.Folder{}
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+
+	dirs := map[int]string{}
+	taken := map[string]bool{}
+
+	var dirOf func(id int, depth int) string
+	dirOf = func(id int, depth int) string {
+		f, ok := byID[id]
+		if !ok || depth > len(folders) {
+			return ""
+		}
+
+		if dir, ok := dirs[id]; ok {
+			return dir
+		}
+
+		parent := dirOf(f.ParentID, depth+1)
+		dirs[id] = uniqueName(path.Join(parent, safeName(f.Name, "Untitled folder")), "", taken)
+
+		return dirs[id]
+	}
+
+	for _, f := range folders {
+		dirOf(f.ID, 0)
+	}
+
+	return dirs
+}
+
+// safeName turns a title into something usable as a single path segment.
+func safeName(name, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, r == 0x7f:
+			return -1
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '-'
+		}
+		return r
+	}, name)
+
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if len([]rune(name)) > 100 {
+		name = string([]rune(name)[:100])
+	}
+
+	if name == "" {
+		return fallback
+	}
+
+	return name
+}
+
+// uniqueName numbers the name, "Name (2)" and so on, when it's taken already.
+func uniqueName(name, ext string, taken map[string]bool) string {
+	candidate := name + ext
+	for i := 2; taken[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", name, i, ext)
+	}
+
+	taken[strings.ToLower(candidate)] = true
+
+	return candidate
+}
+
+// markdownFile returns the note's content preceded by YAML front matter.
+// Strings are written JSON encoded, which YAML reads as double quoted scalars.
+func markdownFile(n note.Note, dir string) string {
+	tags := []string{}
+	for _, tag := range n.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	var b strings.Builder
+
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", quote(n.Title))
+	fmt.Fprintf(&b, "format: %s\n", quote(n.Format))
+	fmt.Fprintf(&b, "tags: %s\n", quote(tags))
+	fmt.Fprintf(&b, "is_public: %t\n", n.IsPublic)
+	if dir != "" {
+		fmt.Fprintf(&b, "folder: %s\n", quote(dir))
+	}
+	fmt.Fprintf(&b, "created_at: %s\n", n.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated_at: %s\n", n.UpdatedAt.UTC().Format(time.RFC3339))
+	b.WriteString("---\n\n")
+	b.WriteString(n.Content)
+
+	if !strings.HasSuffix(n.Content, "\n") {
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func quote(value any) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,12 @@
+package folder
+
+const (
+	DeleteCascade  = "cascade"
+	DeleteReparent = "reparent"
+	DeleteRefuse   = "refuse"
+)
+
+// IsValidDeleteMode reports whether the folder can be deleted in the mode.
+func IsValidDeleteMode(mode string) bool {
+	return mode == DeleteCascade || mode == DeleteReparent || mode == DeleteRefuse
+}
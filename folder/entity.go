@@ -8,6 +8,19 @@ type Folder struct {
 	ParentName string
 	ParentID   int
 	UserID     int
+	NoteCount  int
+	Children   []Folder
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+	DeletedAt  time.Time
+}
+
+// Deletion tells what deleting a folder did, or would do on a dry run.
+type Deletion struct {
+	Mode           string
+	DryRun         bool
+	TrashedFolders int
+	TrashedNotes   int
+	MovedFolders   int
+	MovedNotes     int
 }
@@ -0,0 +1,11 @@
+package folder
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var (
+	ErrNotFound        = helper.NewError(helper.ErrNotFound, "folder_not_found", "folder doesn't exist")
+	ErrParentForbidden = helper.NewError(helper.ErrForbidden, "parent_folder_forbidden", "parent folder isn't one of your folders")
+	ErrMoveCycle       = helper.NewError(helper.ErrConflict, "folder_move_cycle", "a folder cannot be moved into itself or one of its subfolders")
+	ErrNotEmpty        = helper.NewError(helper.ErrConflict, "folder_not_empty", "folder still has notes or subfolders")
+	ErrInvalidMode     = helper.NewError(helper.ErrValidation, "invalid_delete_mode", "mode must be cascade, reparent or refuse")
+)
@@ -1,5 +1,7 @@
 package folder
 
+import "time"
+
 type FolderFormatter struct {
 	ID             int    `json:"id"`
 	Name           string `json:"name"`
@@ -7,6 +9,43 @@ type FolderFormatter struct {
 	ParentFolderID int    `json:"parent_folder_id,omitempty"`
 }
 
+type FolderTreeFormatter struct {
+	ID        int                   `json:"id"`
+	Name      string                `json:"name"`
+	NoteCount int                   `json:"note_count"`
+	Children  []FolderTreeFormatter `json:"children"`
+}
+
+type FolderDetailFormatter struct {
+	ID             int                   `json:"id"`
+	Name           string                `json:"name"`
+	ParentFolder   string                `json:"parent_folder,omitempty"`
+	ParentFolderID int                   `json:"parent_folder_id,omitempty"`
+	Breadcrumbs    []BreadcrumbFormatter `json:"breadcrumbs"`
+}
+
+type BreadcrumbFormatter struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type DeletionFormatter struct {
+	Mode           string `json:"mode"`
+	DryRun         bool   `json:"dry_run"`
+	TrashedFolders int    `json:"trashed_folders"`
+	TrashedNotes   int    `json:"trashed_notes"`
+	MovedFolders   int    `json:"moved_folders"`
+	MovedNotes     int    `json:"moved_notes"`
+}
+
+type TrashedFolderFormatter struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	ParentFolder   string    `json:"parent_folder,omitempty"`
+	ParentFolderID int       `json:"parent_folder_id,omitempty"`
+	DeletedAt      time.Time `json:"deleted_at"`
+}
+
 func FormatFolder(folder Folder) FolderFormatter {
 	return FolderFormatter{
 		ID:             folder.ID,
@@ -26,3 +65,66 @@ func FormatFolders(folders []Folder) []FolderFormatter {
 
 	return folderFormatters
 }
+
+func FormatFolderTree(folders []Folder) []FolderTreeFormatter {
+	treeFormatters := []FolderTreeFormatter{}
+
+	for _, folder := range folders {
+		treeFormatters = append(treeFormatters, FolderTreeFormatter{
+			ID:        folder.ID,
+			Name:      folder.Name,
+			NoteCount: folder.NoteCount,
+			Children:  FormatFolderTree(folder.Children),
+		})
+	}
+
+	return treeFormatters
+}
+
+func FormatFolderDetail(folder Folder, ancestors []Folder) FolderDetailFormatter {
+	breadcrumbs := []BreadcrumbFormatter{}
+
+	for _, ancestor := range ancestors {
+		breadcrumbs = append(breadcrumbs, BreadcrumbFormatter{ID: ancestor.ID, Name: ancestor.Name})
+	}
+
+	return FolderDetailFormatter{
+		ID:             folder.ID,
+		Name:           folder.Name,
+		ParentFolderID: folder.ParentID,
+		ParentFolder:   folder.ParentName,
+		Breadcrumbs:    breadcrumbs,
+	}
+}
+
+func FormatDeletion(deletion Deletion) DeletionFormatter {
+	return DeletionFormatter{
+		Mode:           deletion.Mode,
+		DryRun:         deletion.DryRun,
+		TrashedFolders: deletion.TrashedFolders,
+		TrashedNotes:   deletion.TrashedNotes,
+		MovedFolders:   deletion.MovedFolders,
+		MovedNotes:     deletion.MovedNotes,
+	}
+}
+
+func FormatTrashedFolder(folder Folder) TrashedFolderFormatter {
+	return TrashedFolderFormatter{
+		ID:             folder.ID,
+		Name:           folder.Name,
+		ParentFolderID: folder.ParentID,
+		ParentFolder:   folder.ParentName,
+		DeletedAt:      folder.DeletedAt,
+	}
+}
+
+func FormatTrashedFolders(folders []Folder) []TrashedFolderFormatter {
+	folderFormatters := []TrashedFolderFormatter{}
+
+	for _, folder := range folders {
+		folderFormatter := FormatTrashedFolder(folder)
+		folderFormatters = append(folderFormatters, folderFormatter)
+	}
+
+	return folderFormatters
+}
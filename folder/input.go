@@ -1,11 +1,13 @@
 package folder
 
 type CreateFolderInput struct {
-	Name     string `json:"name"`
-	ParentID int    `json:"parent_folder_id"`
+	Name     string `json:"name" validate:"required,max=255"`
+	ParentID int    `json:"parent_folder_id" validate:"min=0"`
 }
 
+// UpdateFolderInput leaves the parent alone when ParentID is 0, and moves the
+// folder to the root when it's negative.
 type UpdateFolderInput struct {
-	Name     string `json:"name"`
+	Name     string `json:"name" validate:"required,max=255"`
 	ParentID int    `json:"parent_folder_id"`
 }
@@ -0,0 +1,66 @@
+package folder
+
+import (
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+)
+
+const (
+	SortUpdatedAt = "updated_at"
+	SortCreatedAt = "created_at"
+	SortName      = "name"
+)
+
+var Sorts = []string{SortUpdatedAt, SortCreatedAt, SortName}
+
+var sortColumns = map[string]string{
+	SortUpdatedAt: "f.updated_at",
+	SortCreatedAt: "f.created_at",
+	SortName:      "f.name",
+}
+
+// pageClause returns the condition seeking past the page's cursor and the
+// ORDER BY/LIMIT for the page's sort.
+func pageClause(page helper.Page) (string, []any, string, error) {
+	column, ok := sortColumns[page.Sort]
+	if !ok {
+		return "", nil, "", helper.ErrInvalidSort
+	}
+
+	var value any = page.Cursor.Value
+	if page.Sort != SortName && page.Cursor != (helper.Cursor{}) {
+		parsed, err := time.Parse(time.RFC3339Nano, page.Cursor.Value)
+		if err != nil {
+			return "", nil, "", helper.ErrInvalidCursor
+		}
+
+		value = parsed
+	}
+
+	condition, args, orderLimit := page.Keyset(column, "f.id", value)
+
+	return condition, args, orderLimit, nil
+}
+
+// paginate trims the extra row fetched by pageClause and builds the
+// pagination meta from the last folder kept.
+func paginate(folders []Folder, page helper.Page) ([]Folder, helper.Pagination) {
+	if len(folders) <= page.Limit {
+		return folders, page.Next(len(folders), helper.Cursor{})
+	}
+
+	last := folders[page.Limit-1]
+
+	var cursor helper.Cursor
+	switch page.Sort {
+	case SortUpdatedAt:
+		cursor = helper.Cursor{Value: last.UpdatedAt.Format(time.RFC3339Nano), ID: last.ID}
+	case SortCreatedAt:
+		cursor = helper.Cursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+	case SortName:
+		cursor = helper.Cursor{Value: last.Name, ID: last.ID}
+	}
+
+	return folders[:page.Limit], page.Next(len(folders), cursor)
+}
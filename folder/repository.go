@@ -2,33 +2,55 @@ package folder
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
 )
 
 type Repository interface {
+	WithTx(tx *sql.Tx) Repository
 	FindByID(userID, id int) (Folder, error)
-	FindByUserID(userID int) ([]Folder, error)
-	FindByParentID(userID, parentID int) ([]Folder, error)
+	FindByUserID(userID int, page helper.Page) ([]Folder, error)
+	FindByParentID(userID, parentID int, page helper.Page) ([]Folder, error)
+	FindTreeByUserID(userID int) ([]Folder, error)
+	FindAncestors(userID, id int) ([]Folder, error)
+	CountByUserID(userID int) (int, error)
+	CountByParentID(userID, parentID int) (int, error)
 	Save(folder Folder) (Folder, error)
 	SaveWithParentID(folder Folder) (Folder, error)
 	Update(folder Folder) (Folder, error)
 	UpdateWithParentID(folder Folder, parentID any) (Folder, error)
+	CountSubtree(folderID int) (folders, notes int, err error)
+	CountChildren(folderID int) (folders, notes int, err error)
+	Reparent(folder Folder) error
 	Delete(folder Folder) error
+	FindTrashedByUserID(userID int) ([]Folder, error)
+	FindTrashedByID(userID, id int) (Folder, error)
+	Restore(folder Folder) error
+	ForceDelete(folder Folder) error
+	ForceDeleteTrashedBefore(before time.Time) error
 }
 
 type repository struct {
-	db *sql.DB
+	db transaction.DBTX
 }
 
 func NewRepository(db *sql.DB) *repository {
 	return &repository{db}
 }
 
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{tx}
+}
+
 func (r *repository) FindByID(userID, id int) (Folder, error) {
 	var folder Folder
 
 	query := "SELECT f.id, f.name, COALESCE(f.parent_id, 0), f.user_id, f.created_at, f.updated_at, COALESCE(p.name, '') " +
-		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.user_id = ? AND f.id = ?"
+		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.user_id = ? AND f.id = ? AND f.deleted_at IS NULL"
 
 	err := r.db.QueryRow(query, userID, id).Scan(
 		&folder.ID, &folder.Name, &folder.ParentID,
@@ -41,17 +63,24 @@ func (r *repository) FindByID(userID, id int) (Folder, error) {
 	return folder, nil
 }
 
-func (r *repository) FindByUserID(userID int) ([]Folder, error) {
+func (r *repository) FindByUserID(userID int, page helper.Page) ([]Folder, error) {
 
 	var folders []Folder
 
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return folders, err
+	}
+
 	query := "SELECT f.id, f.name, COALESCE(f.parent_id, 0), f.user_id, f.created_at, f.updated_at, COALESCE(p.name, '') " +
-		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.user_id = ?"
+		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.user_id = ? AND f.deleted_at IS NULL " +
+		"AND " + pageCondition + " " + orderLimit
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Query(query, append([]any{userID}, pageArgs...)...)
 	if err != nil {
 		return folders, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var folder Folder
@@ -68,17 +97,24 @@ func (r *repository) FindByUserID(userID int) ([]Folder, error) {
 	return folders, nil
 }
 
-func (r *repository) FindByParentID(userID, parentID int) ([]Folder, error) {
+func (r *repository) FindByParentID(userID, parentID int, page helper.Page) ([]Folder, error) {
 
 	var folders []Folder
 
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return folders, err
+	}
+
 	query := "SELECT f.id, f.name, COALESCE(f.parent_id, 0), f.user_id, f.created_at, f.updated_at, COALESCE(p.name, '') " +
-		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.parent_id = ? AND f.user_id = ?"
+		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.parent_id = ? AND f.user_id = ? AND f.deleted_at IS NULL " +
+		"AND " + pageCondition + " " + orderLimit
 
-	rows, err := r.db.Query(query, parentID, userID)
+	rows, err := r.db.Query(query, append([]any{parentID, userID}, pageArgs...)...)
 	if err != nil {
 		return folders, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var folder Folder
@@ -95,6 +131,82 @@ func (r *repository) FindByParentID(userID, parentID int) ([]Folder, error) {
 	return folders, nil
 }
 
+// FindTreeByUserID returns all of the user's folders, unpaginated, along with
+// how many notes each one holds directly.
+func (r *repository) FindTreeByUserID(userID int) ([]Folder, error) {
+	var folders []Folder
+
+	query := "SELECT f.id, f.name, COALESCE(f.parent_id, 0), f.user_id, f.created_at, f.updated_at, COUNT(n.id) " +
+		"FROM folders f LEFT JOIN notes n ON n.folder_id = f.id AND n.deleted_at IS NULL " +
+		"WHERE f.user_id = ? AND f.deleted_at IS NULL GROUP BY f.id ORDER BY f.name, f.id"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return folders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var folder Folder
+		if err := rows.Scan(
+			&folder.ID, &folder.Name, &folder.ParentID,
+			&folder.UserID, &folder.CreatedAt, &folder.UpdatedAt, &folder.NoteCount,
+		); err != nil {
+			return folders, err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+// FindAncestors walks up the parent_id chain of the folder and returns its
+// ancestors starting from the root. The folder itself isn't included.
+func (r *repository) FindAncestors(userID, id int) ([]Folder, error) {
+	var folders []Folder
+
+	query := "WITH RECURSIVE ancestors AS (" +
+		"SELECT id, name, parent_id, user_id, 0 AS depth FROM folders WHERE id = ? AND user_id = ? " +
+		"UNION ALL SELECT p.id, p.name, p.parent_id, p.user_id, a.depth + 1 FROM folders p JOIN ancestors a ON p.id = a.parent_id" +
+		") SELECT id, name, COALESCE(parent_id, 0), user_id FROM ancestors WHERE depth > 0 ORDER BY depth DESC"
+
+	rows, err := r.db.Query(query, id, userID)
+	if err != nil {
+		return folders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var folder Folder
+		if err := rows.Scan(&folder.ID, &folder.Name, &folder.ParentID, &folder.UserID); err != nil {
+			return folders, err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+func (r *repository) CountByUserID(userID int) (int, error) {
+	var total int
+
+	query := "SELECT COUNT(*) FROM folders WHERE user_id = ? AND deleted_at IS NULL"
+
+	err := r.db.QueryRow(query, userID).Scan(&total)
+	return total, err
+}
+
+func (r *repository) CountByParentID(userID, parentID int) (int, error) {
+	var total int
+
+	query := "SELECT COUNT(*) FROM folders WHERE parent_id = ? AND user_id = ? AND deleted_at IS NULL"
+
+	err := r.db.QueryRow(query, parentID, userID).Scan(&total)
+	return total, err
+}
+
 func (r *repository) Save(folder Folder) (Folder, error) {
 	query := "INSERT INTO folders SET " +
 		"name = ?, user_id = ?, created_at = NOW(), updated_at = NOW()"
@@ -165,9 +277,225 @@ func (r *repository) UpdateWithParentID(folder Folder, parentID any) (Folder, er
 	return folder, nil
 }
 
+// CountSubtree counts the folders in the folder's subtree, itself included,
+// and the notes in them, leaving out whatever is in the trash already.
+func (r *repository) CountSubtree(folderID int) (folders, notes int, err error) {
+	folderIDs, err := r.findSubtreeIDs(folderID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	questionMarks, fields := inClause(folderIDs)
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM folders WHERE deleted_at IS NULL AND id IN (%s)", questionMarks)
+	if err := r.db.QueryRow(query, fields...).Scan(&folders); err != nil {
+		return 0, 0, err
+	}
+
+	query = fmt.Sprintf("SELECT COUNT(*) FROM notes WHERE deleted_at IS NULL AND folder_id IN (%s)", questionMarks)
+	if err := r.db.QueryRow(query, fields...).Scan(&notes); err != nil {
+		return 0, 0, err
+	}
+
+	return folders, notes, nil
+}
+
+// CountChildren counts the subfolders and notes directly in the folder.
+func (r *repository) CountChildren(folderID int) (folders, notes int, err error) {
+	query := "SELECT COUNT(*) FROM folders WHERE parent_id = ? AND deleted_at IS NULL"
+	if err := r.db.QueryRow(query, folderID).Scan(&folders); err != nil {
+		return 0, 0, err
+	}
+
+	query = "SELECT COUNT(*) FROM notes WHERE folder_id = ? AND deleted_at IS NULL"
+	if err := r.db.QueryRow(query, folderID).Scan(&notes); err != nil {
+		return 0, 0, err
+	}
+
+	return folders, notes, nil
+}
+
+// Reparent moves the folder's subfolders and notes up into its parent, or to
+// the root when it has none.
+func (r *repository) Reparent(folder Folder) error {
+	var parentID any
+	if folder.ParentID > 0 {
+		parentID = folder.ParentID
+	}
+
+	query := "UPDATE folders SET parent_id = ?, updated_at = NOW() WHERE parent_id = ? AND deleted_at IS NULL"
+	if _, err := r.db.Exec(query, parentID, folder.ID); err != nil {
+		return err
+	}
+
+	query = "UPDATE notes SET folder_id = ?, updated_at = NOW() WHERE folder_id = ? AND deleted_at IS NULL"
+
+	_, err := r.db.Exec(query, parentID, folder.ID)
+	return err
+}
+
+// Delete moves the folder, its descendants and their notes to the trash,
+// stamping them all with the same deleted_at so they can be restored together.
 func (r *repository) Delete(folder Folder) error {
-	query := "DELETE FROM folders WHERE id = ?"
+	folderIDs, err := r.findSubtreeIDs(folder.ID)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	questionMarks, fields := inClause(folderIDs)
+
+	query := fmt.Sprintf("UPDATE notes SET deleted_at = ? WHERE deleted_at IS NULL AND folder_id IN (%s)", questionMarks)
+	if _, err := r.db.Exec(query, append([]any{deletedAt}, fields...)...); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf("UPDATE folders SET deleted_at = ? WHERE deleted_at IS NULL AND id IN (%s)", questionMarks)
+	_, err = r.db.Exec(query, append([]any{deletedAt}, fields...)...)
+	return err
+}
+
+func (r *repository) FindTrashedByUserID(userID int) ([]Folder, error) {
+	var folders []Folder
+
+	// Descendants trashed together with their parent are listed under that parent instead
+	query := "SELECT f.id, f.name, COALESCE(f.parent_id, 0), f.user_id, f.created_at, f.updated_at, f.deleted_at, COALESCE(p.name, '') " +
+		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id " +
+		"WHERE f.user_id = ? AND f.deleted_at IS NOT NULL AND (p.id IS NULL OR p.deleted_at IS NULL OR p.deleted_at <> f.deleted_at) " +
+		"ORDER BY f.deleted_at DESC"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return folders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var folder Folder
+		if err := rows.Scan(
+			&folder.ID, &folder.Name, &folder.ParentID,
+			&folder.UserID, &folder.CreatedAt, &folder.UpdatedAt, &folder.DeletedAt, &folder.ParentName,
+		); err != nil {
+			return folders, err
+		}
+
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+func (r *repository) FindTrashedByID(userID, id int) (Folder, error) {
+	var folder Folder
+
+	query := "SELECT f.id, f.name, COALESCE(f.parent_id, 0), f.user_id, f.created_at, f.updated_at, f.deleted_at, COALESCE(p.name, '') " +
+		"FROM folders f LEFT JOIN folders p ON f.parent_id = p.id WHERE f.user_id = ? AND f.id = ? AND f.deleted_at IS NOT NULL"
+
+	err := r.db.QueryRow(query, userID, id).Scan(
+		&folder.ID, &folder.Name, &folder.ParentID,
+		&folder.UserID, &folder.CreatedAt, &folder.UpdatedAt, &folder.DeletedAt, &folder.ParentName,
+	)
+	if err != nil {
+		return folder, err
+	}
+
+	return folder, nil
+}
 
-	_, err := r.db.Exec(query, folder.ID)
+func (r *repository) Restore(folder Folder) error {
+	folderIDs, err := r.findSubtreeIDs(folder.ID)
+	if err != nil {
+		return err
+	}
+
+	questionMarks, fields := inClause(folderIDs)
+
+	query := fmt.Sprintf("UPDATE notes SET deleted_at = NULL WHERE deleted_at = ? AND folder_id IN (%s)", questionMarks)
+	if _, err := r.db.Exec(query, append([]any{folder.DeletedAt}, fields...)...); err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf("UPDATE folders SET deleted_at = NULL WHERE deleted_at = ? AND id IN (%s)", questionMarks)
+	if _, err := r.db.Exec(query, append([]any{folder.DeletedAt}, fields...)...); err != nil {
+		return err
+	}
+
+	// A folder whose parent is still in the trash is moved back to the root
+	query = "UPDATE folders f LEFT JOIN folders p ON f.parent_id = p.id " +
+		"SET f.parent_id = IF(p.deleted_at IS NULL, f.parent_id, NULL), f.updated_at = NOW() " +
+		"WHERE f.id = ?"
+
+	_, err = r.db.Exec(query, folder.ID)
+	return err
+}
+
+func (r *repository) ForceDelete(folder Folder) error {
+	folderIDs, err := r.findSubtreeIDs(folder.ID)
+	if err != nil {
+		return err
+	}
+
+	// notes.folder_id has no ON DELETE rule, so the notes have to go first
+	questionMarks, fields := inClause(folderIDs)
+
+	query := fmt.Sprintf("DELETE FROM notes WHERE folder_id IN (%s)", questionMarks)
+	if _, err := r.db.Exec(query, fields...); err != nil {
+		return err
+	}
+
+	query = "DELETE FROM folders WHERE id = ?"
+
+	_, err = r.db.Exec(query, folder.ID)
+	return err
+}
+
+func (r *repository) ForceDeleteTrashedBefore(before time.Time) error {
+	query := "DELETE FROM notes WHERE folder_id IN " +
+		"(SELECT id FROM folders WHERE deleted_at IS NOT NULL AND deleted_at < ?)"
+
+	if _, err := r.db.Exec(query, before); err != nil {
+		return err
+	}
+
+	query = "DELETE FROM folders WHERE deleted_at IS NOT NULL AND deleted_at < ?"
+
+	_, err := r.db.Exec(query, before)
 	return err
 }
+
+func (r *repository) findSubtreeIDs(folderID int) ([]int, error) {
+	var folderIDs []int
+
+	query := "WITH RECURSIVE subtree AS (" +
+		"SELECT id FROM folders WHERE id = ? " +
+		"UNION ALL SELECT f.id FROM folders f JOIN subtree s ON f.parent_id = s.id" +
+		") SELECT id FROM subtree"
+
+	rows, err := r.db.Query(query, folderID)
+	if err != nil {
+		return folderIDs, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return folderIDs, err
+		}
+
+		folderIDs = append(folderIDs, id)
+	}
+
+	return folderIDs, nil
+}
+
+func inClause(ids []int) (string, []any) {
+	questionMarks := []string{}
+	fields := []any{}
+	for _, id := range ids {
+		questionMarks = append(questionMarks, "?")
+		fields = append(fields, id)
+	}
+
+	return strings.Join(questionMarks, ","), fields
+}
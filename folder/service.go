@@ -1,44 +1,135 @@
 package folder
 
-import "errors"
+import (
+	"database/sql"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
+)
 
 type Service interface {
-	FindFolders(userID int, folderID int) ([]Folder, error)
+	FindFolders(userID int, folderID int, page helper.Page) ([]Folder, helper.Pagination, error)
+	FindFolderTree(userID int) ([]Folder, error)
+	FindFolder(userID, folderID int) (Folder, []Folder, error)
 	CreateFolder(input CreateFolderInput, userID int) (Folder, error)
 	UpdateFolder(input UpdateFolderInput, userID, folderID int) (Folder, error)
-	DeleteFolder(userID, folderID int) error
+	DeleteFolder(userID, folderID int, mode string, dryRun bool) (Deletion, error)
+	FindTrashedFolders(userID int) ([]Folder, error)
+	RestoreFolder(userID, folderID int) (Folder, error)
+	PurgeFolder(userID, folderID int) error
+	PurgeTrashedFolders(before time.Time) error
 }
 
 type service struct {
-	repository Repository
+	repository   Repository
+	transactions transaction.Manager
 }
 
-func NewService(repository Repository) *service {
-	return &service{repository}
+func NewService(repository Repository, transactions transaction.Manager) *service {
+	return &service{repository, transactions}
 }
 
-func (s *service) FindFolders(userID int, folderID int) ([]Folder, error) {
+func (s *service) FindFolders(userID int, folderID int, page helper.Page) ([]Folder, helper.Pagination, error) {
 	var folders []Folder
+	var pagination helper.Pagination
 
 	if userID == 0 {
-		return folders, errors.New("no user available on this session")
+		return folders, pagination, helper.ErrNoSession
+	}
+
+	page, err := page.WithDefaultSort(SortUpdatedAt)
+	if err != nil {
+		return folders, pagination, err
 	}
 
+	var total int
+
 	if folderID != 0 {
-		folders, err := s.repository.FindByParentID(userID, folderID)
+		folders, err = s.repository.FindByParentID(userID, folderID, page)
+		if err != nil {
+			return folders, pagination, err
+		}
+
+		total, err = s.repository.CountByParentID(userID, folderID)
+	} else {
+		folders, err = s.repository.FindByUserID(userID, page)
 		if err != nil {
-			return folders, err
+			return folders, pagination, err
 		}
 
-		return folders, nil
+		total, err = s.repository.CountByUserID(userID)
+	}
+	if err != nil {
+		return folders, pagination, err
+	}
+
+	folders, pagination = paginate(folders, page)
+	pagination.Total = &total
+
+	return folders, pagination, nil
+}
+
+// FindFolderTree returns the user's root folders with every descendant
+// nested under its parent.
+func (s *service) FindFolderTree(userID int) ([]Folder, error) {
+	if userID == 0 {
+		return nil, helper.ErrNoSession
 	}
 
-	folders, err := s.repository.FindByUserID(userID)
+	folders, err := s.repository.FindTreeByUserID(userID)
 	if err != nil {
 		return folders, err
 	}
 
-	return folders, nil
+	return buildTree(folders), nil
+}
+
+// FindFolder returns the folder along with its breadcrumb, the ancestors
+// leading down to it from the root.
+func (s *service) FindFolder(userID, folderID int) (Folder, []Folder, error) {
+	folder, err := s.repository.FindByID(userID, folderID)
+	if err != nil {
+		return folder, nil, helper.NoRows(err, ErrNotFound)
+	}
+
+	ancestors, err := s.repository.FindAncestors(userID, folderID)
+	if err != nil {
+		return folder, nil, err
+	}
+
+	return folder, ancestors, nil
+}
+
+// buildTree nests the folders under their parents. A folder whose parent
+// isn't among them is treated as a root.
+func buildTree(folders []Folder) []Folder {
+	known := map[int]bool{}
+	for _, folder := range folders {
+		known[folder.ID] = true
+	}
+
+	children := map[int][]Folder{}
+	for _, folder := range folders {
+		parentID := folder.ParentID
+		if !known[parentID] {
+			parentID = 0
+		}
+
+		children[parentID] = append(children[parentID], folder)
+	}
+
+	var attach func(parentID int) []Folder
+	attach = func(parentID int) []Folder {
+		nodes := children[parentID]
+		for i := range nodes {
+			nodes[i].Children = attach(nodes[i].ID)
+		}
+
+		return nodes
+	}
+
+	return attach(0)
 }
 
 func (s *service) CreateFolder(input CreateFolderInput, userID int) (Folder, error) {
@@ -57,6 +148,10 @@ func (s *service) CreateFolder(input CreateFolderInput, userID int) (Folder, err
 		return newFolder, nil
 	}
 
+	if err := checkParent(s.repository, userID, 0, folder.ParentID); err != nil {
+		return folder, err
+	}
+
 	newFolder, err := s.repository.SaveWithParentID(folder)
 	if err != nil {
 		return newFolder, err
@@ -68,7 +163,7 @@ func (s *service) CreateFolder(input CreateFolderInput, userID int) (Folder, err
 func (s *service) UpdateFolder(input UpdateFolderInput, userID, folderID int) (Folder, error) {
 	currentFolder, err := s.repository.FindByID(userID, folderID)
 	if err != nil {
-		return currentFolder, err
+		return currentFolder, helper.NoRows(err, ErrNotFound)
 	}
 
 	currentFolder.Name = input.Name
@@ -85,6 +180,10 @@ func (s *service) UpdateFolder(input UpdateFolderInput, userID, folderID int) (F
 
 	var parentID any
 	if currentFolder.ParentID > 0 {
+		if err := checkParent(s.repository, userID, folderID, currentFolder.ParentID); err != nil {
+			return currentFolder, err
+		}
+
 		parentID = currentFolder.ParentID
 	} else {
 		parentID = nil
@@ -100,11 +199,126 @@ func (s *service) UpdateFolder(input UpdateFolderInput, userID, folderID int) (F
 	return newFolder, nil
 }
 
-func (s *service) DeleteFolder(userID, folderID int) error {
-	currentFolder, err := s.repository.FindByID(userID, folderID)
+// checkParent makes sure the parent is one of the user's folders and, when
+// moving an existing folder, that it isn't the folder itself or one of its
+// subfolders, which would cut the subtree off from the root.
+func checkParent(repository Repository, userID, folderID, parentID int) error {
+	if parentID == folderID {
+		return ErrMoveCycle
+	}
+
+	if _, err := repository.FindByID(userID, parentID); err != nil {
+		return helper.NoRows(err, ErrParentForbidden)
+	}
+
+	if folderID == 0 {
+		return nil
+	}
+
+	ancestors, err := repository.FindAncestors(userID, parentID)
 	if err != nil {
 		return err
 	}
 
-	return s.repository.Delete(currentFolder)
+	for _, ancestor := range ancestors {
+		if ancestor.ID == folderID {
+			return ErrMoveCycle
+		}
+	}
+
+	return nil
+}
+
+// DeleteFolder moves the folder to the trash. In cascade mode everything in
+// it goes along, in reparent mode its contents move up a level first, and in
+// refuse mode only an empty folder is deleted. A dry run reports what would
+// happen without changing anything.
+func (s *service) DeleteFolder(userID, folderID int, mode string, dryRun bool) (Deletion, error) {
+	deletion := Deletion{Mode: mode, DryRun: dryRun}
+	if deletion.Mode == "" {
+		deletion.Mode = DeleteCascade
+	}
+
+	if !IsValidDeleteMode(deletion.Mode) {
+		return deletion, ErrInvalidMode
+	}
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		currentFolder, err := repository.FindByID(userID, folderID)
+		if err != nil {
+			return helper.NoRows(err, ErrNotFound)
+		}
+
+		if deletion.Mode == DeleteReparent {
+			deletion.TrashedFolders = 1
+			deletion.MovedFolders, deletion.MovedNotes, err = repository.CountChildren(currentFolder.ID)
+		} else {
+			deletion.TrashedFolders, deletion.TrashedNotes, err = repository.CountSubtree(currentFolder.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if deletion.Mode == DeleteRefuse && (deletion.TrashedFolders > 1 || deletion.TrashedNotes > 0) {
+			return ErrNotEmpty
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		if deletion.Mode == DeleteReparent {
+			if err := repository.Reparent(currentFolder); err != nil {
+				return err
+			}
+		}
+
+		return repository.Delete(currentFolder)
+	})
+
+	return deletion, err
+}
+
+func (s *service) FindTrashedFolders(userID int) ([]Folder, error) {
+	var folders []Folder
+
+	if userID == 0 {
+		return folders, helper.ErrNoSession
+	}
+
+	return s.repository.FindTrashedByUserID(userID)
+}
+
+func (s *service) RestoreFolder(userID, folderID int) (Folder, error) {
+	trashedFolder, err := s.repository.FindTrashedByID(userID, folderID)
+	if err != nil {
+		return trashedFolder, helper.NoRows(err, ErrNotFound)
+	}
+
+	if err := s.transactions.Run(func(tx *sql.Tx) error {
+		return s.repository.WithTx(tx).Restore(trashedFolder)
+	}); err != nil {
+		return trashedFolder, err
+	}
+
+	return s.repository.FindByID(userID, folderID)
+}
+
+func (s *service) PurgeFolder(userID, folderID int) error {
+	trashedFolder, err := s.repository.FindTrashedByID(userID, folderID)
+	if err != nil {
+		return helper.NoRows(err, ErrNotFound)
+	}
+
+	return s.transactions.Run(func(tx *sql.Tx) error {
+		return s.repository.WithTx(tx).ForceDelete(trashedFolder)
+	})
+}
+
+func (s *service) PurgeTrashedFolders(before time.Time) error {
+	return s.transactions.Run(func(tx *sql.Tx) error {
+		return s.repository.WithTx(tx).ForceDeleteTrashedBefore(before)
+	})
 }
@@ -0,0 +1,54 @@
+package folder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTree(t *testing.T) {
+	node := func(id, parentID int, children ...Folder) Folder {
+		return Folder{ID: id, ParentID: parentID, Children: children}
+	}
+
+	tests := []struct {
+		name    string
+		folders []Folder
+		want    []Folder
+	}{
+		{"empty", nil, nil},
+		{"roots only", []Folder{node(1, 0), node(2, 0)}, []Folder{node(1, 0), node(2, 0)}},
+		{
+			name:    "nested",
+			folders: []Folder{node(1, 0), node(2, 1), node(3, 2), node(4, 1)},
+			want:    []Folder{node(1, 0, node(2, 1, node(3, 2)), node(4, 1))},
+		},
+		{
+			name:    "children listed before their parent",
+			folders: []Folder{node(3, 2), node(2, 1), node(1, 0)},
+			want:    []Folder{node(1, 0, node(2, 1, node(3, 2)))},
+		},
+		{
+			name:    "keeps the order of siblings",
+			folders: []Folder{node(1, 0), node(5, 1), node(3, 1), node(4, 1)},
+			want:    []Folder{node(1, 0, node(5, 1), node(3, 1), node(4, 1))},
+		},
+		{
+			name:    "orphaned parent",
+			folders: []Folder{node(2, 9), node(3, 2), node(1, 0)},
+			want:    []Folder{node(2, 9, node(3, 2)), node(1, 0)},
+		},
+		{
+			name:    "only orphans",
+			folders: []Folder{node(2, 8), node(3, 9)},
+			want:    []Folder{node(2, 8), node(3, 9)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTree(tt.folders); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildTree() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
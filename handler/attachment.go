@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"mime"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/attachment"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type attachmentHandler struct {
+	attachmentService attachment.Service
+}
+
+func NewAttachmentHandler(attachmentService attachment.Service) *attachmentHandler {
+	return &attachmentHandler{attachmentService}
+}
+
+func (h *attachmentHandler) FindAttachments(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	attachments, err := h.attachmentService.FindAttachments(currentUser.ID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched note's attachments", "success", fiber.StatusOK, attachment.FormatAttachments(attachments)),
+	)
+}
+
+// UploadAttachment attaches the file sent in the multipart field file.
+func (h *attachmentHandler) UploadAttachment(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(
+			helper.APIValidationResponse(helper.ValidationErrors{"file": "is required"}),
+		)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	newAttachment, err := h.attachmentService.UploadAttachment(currentUser.ID, noteID, fileHeader.Filename, file, fileHeader.Size)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(
+		helper.APIResponse("Successfully uploaded the attachment", "success", fiber.StatusCreated, attachment.FormatAttachment(newAttachment)),
+	)
+}
+
+// DownloadAttachment streams the file. It's always sent as a download and
+// never sniffed, so an uploaded HTML or SVG file can't run in the API's
+// origin.
+func (h *attachmentHandler) DownloadAttachment(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	attachmentID, err := strconv.Atoi(c.Params("attachment_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your attachment id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	fetchedAttachment, contents, err := h.attachmentService.OpenAttachment(currentUser.ID, noteID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fetchedAttachment.ContentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": fetchedAttachment.Filename}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	// The stream is closed once it has been sent
+	return c.Status(fiber.StatusOK).SendStream(contents, int(fetchedAttachment.Size))
+}
+
+// DownloadThumbnail streams a thumbnail of an image attachment. Thumbnails
+// are re-encoded without metadata, so unlike originals they can be shown
+// inline.
+func (h *attachmentHandler) DownloadThumbnail(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	attachmentID, err := strconv.Atoi(c.Params("attachment_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your attachment id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	thumbnail, contents, err := h.attachmentService.OpenThumbnail(currentUser.ID, noteID, attachmentID, c.Params("size"))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, thumbnail.ContentType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+
+	return c.Status(fiber.StatusOK).SendStream(contents)
+}
+
+func (h *attachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	attachmentID, err := strconv.Atoi(c.Params("attachment_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your attachment id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.attachmentService.DeleteAttachment(currentUser.ID, noteID, attachmentID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully deleted the attachment", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *attachmentHandler) FindUsage(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	usage, err := h.attachmentService.FindUsage(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched storage usage", "success", fiber.StatusOK, attachment.FormatUsage(usage)),
+	)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+)
+
+var errorStatuses = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{helper.ErrValidation, fiber.StatusUnprocessableEntity, "validation_failed"},
+	{helper.ErrUnauthorized, fiber.StatusUnauthorized, "unauthorized"},
+	{helper.ErrForbidden, fiber.StatusForbidden, "forbidden"},
+	{helper.ErrNotFound, fiber.StatusNotFound, "not_found"},
+	{helper.ErrConflict, fiber.StatusConflict, "conflict"},
+	{helper.ErrTooManyRequests, fiber.StatusTooManyRequests, "too_many_requests"},
+	{helper.ErrTooLarge, fiber.StatusRequestEntityTooLarge, "too_large"},
+}
+
+// ErrorHandler answers whatever error a handler returned. Domain errors get
+// the status of their kind along with their code, anything else is logged
+// and hidden behind a 500.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(
+			helper.APIErrorResponse(fiberErr.Message, fiberErr.Code, ""),
+		)
+	}
+
+	var retryable interface{ RetryAfter() time.Duration }
+	if errors.As(err, &retryable) {
+		seconds := int(math.Ceil(retryable.RetryAfter().Seconds()))
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	}
+
+	for _, e := range errorStatuses {
+		if !errors.Is(err, e.kind) {
+			continue
+		}
+
+		code := e.code
+
+		var domainErr *helper.Error
+		if errors.As(err, &domainErr) {
+			code = domainErr.Code
+		}
+
+		return c.Status(e.status).JSON(helper.APIErrorResponse(err.Error(), e.status, code))
+	}
+
+	log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+
+	return c.Status(fiber.StatusInternalServerError).JSON(
+		helper.APIErrorResponse("Something went wrong on our side", fiber.StatusInternalServerError, "internal_error"),
+	)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/export"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type exportHandler struct {
+	exportService export.Service
+}
+
+func NewExportHandler(exportService export.Service) *exportHandler {
+	return &exportHandler{exportService}
+}
+
+// ExportNotes streams the user's notes as a zip or JSON download. Once streaming has
+// started the status can't change anymore, so a failure from then on only
+// cuts the archive short and gets logged.
+func (h *exportHandler) ExportNotes(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	archive, err := h.exportService.ExportNotes(currentUser.ID, c.Query("format"))
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("easynote-export-%s.%s", time.Now().Format("2006-01-02"), archive.Format)
+
+	contentType := "application/zip"
+	if archive.Format == export.FormatJSON {
+		contentType = fiber.MIMEApplicationJSON
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := archive.Write(w); err != nil {
+			log.Println("export:", err)
+		}
+	})
+
+	return nil
+}
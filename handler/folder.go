@@ -21,15 +21,51 @@ func (h *folderHandler) FindFolders(c *fiber.Ctx) error {
 	currentUser := c.Locals("currentUser").(user.User)
 	folderID, _ := strconv.Atoi(c.Query("parent_id"))
 
-	folders, err := h.folderService.FindFolders(currentUser.ID, folderID)
+	page, err := helper.NewPage(c.QueryInt("limit"), c.Query("cursor"), c.Query("sort"), c.Query("direction"), folder.Sorts)
+	if err != nil {
+		return err
+	}
+
+	folders, pagination, err := h.folderService.FindFolders(currentUser.ID, folderID, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIPaginatedResponse("Successfully fetched folder's list", "success", fiber.StatusOK, folder.FormatFolders(folders), pagination),
+	)
+}
+
+func (h *folderHandler) FindFolderTree(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	folders, err := h.folderService.FindFolderTree(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched folder tree", "success", fiber.StatusOK, folder.FormatFolderTree(folders)),
+	)
+}
+
+func (h *folderHandler) FindFolder(c *fiber.Ctx) error {
+	folderID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(
-			helper.APIResponse("Cannot fetch folders", "error", fiber.StatusBadRequest, nil),
+			helper.APIResponse("There's something wrong with your folder id", "error", fiber.StatusBadRequest, nil),
 		)
 	}
 
+	currentUser := c.Locals("currentUser").(user.User)
+
+	fetchedFolder, ancestors, err := h.folderService.FindFolder(currentUser.ID, folderID)
+	if err != nil {
+		return err
+	}
+
 	return c.Status(fiber.StatusOK).JSON(
-		helper.APIResponse("Successfully fetched folder's list", "success", fiber.StatusOK, folder.FormatFolders(folders)),
+		helper.APIResponse("Successfully fetched the folder", "success", fiber.StatusOK, folder.FormatFolderDetail(fetchedFolder, ancestors)),
 	)
 }
 
@@ -43,13 +79,15 @@ func (h *folderHandler) CreateFolder(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	currentUser := c.Locals("currentUser").(user.User)
 
 	newFolder, err := h.folderService.CreateFolder(input, currentUser.ID)
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot create new folder", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -67,6 +105,10 @@ func (h *folderHandler) UpdateFolder(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	folderID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(
@@ -78,9 +120,7 @@ func (h *folderHandler) UpdateFolder(c *fiber.Ctx) error {
 
 	updatedFolder, err := h.folderService.UpdateFolder(input, currentUser.ID, folderID)
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot update the folder", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -98,13 +138,17 @@ func (h *folderHandler) DeleteFolder(c *fiber.Ctx) error {
 
 	currentUser := c.Locals("currentUser").(user.User)
 
-	if err := h.folderService.DeleteFolder(currentUser.ID, folderID); err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot delete the folder", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+	deletion, err := h.folderService.DeleteFolder(currentUser.ID, folderID, c.Query("mode"), c.QueryBool("dry_run"))
+	if err != nil {
+		return err
+	}
+
+	message := "Successfully deleted the folder"
+	if deletion.DryRun {
+		message = "Nothing was deleted, this is what the deletion would do"
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
-		helper.APIResponse("Successfully deleted the folder", "success", fiber.StatusOK, nil),
+		helper.APIResponse(message, "success", fiber.StatusOK, folder.FormatDeletion(deletion)),
 	)
 }
\ No newline at end of file
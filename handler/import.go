@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/importer"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type importHandler struct {
+	importService importer.Service
+}
+
+func NewImportHandler(importService importer.Service) *importHandler {
+	return &importHandler{importService}
+}
+
+// StartImport takes the upload in the multipart field file and answers with
+// the queued job, whose progress can then be followed on FindImport.
+func (h *importHandler) StartImport(c *fiber.Ctx) error {
+	var input importer.ImportInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(
+			helper.APIValidationResponse(helper.ValidationErrors{"file": "is required"}),
+		)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	job, err := h.importService.StartImport(input, fileHeader.Filename, data, currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(
+		helper.APIResponse("Import has been queued", "success", fiber.StatusAccepted, importer.FormatJob(job)),
+	)
+}
+
+func (h *importHandler) FindImport(c *fiber.Ctx) error {
+	jobID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your import id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	job, err := h.importService.FindJob(currentUser.ID, jobID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the import", "success", fiber.StatusOK, importer.FormatJob(job)),
+	)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/auth"
+)
+
+type jwksHandler struct {
+	authService auth.Service
+}
+
+func NewJWKSHandler(authService auth.Service) *jwksHandler {
+	return &jwksHandler{authService}
+}
+
+// FindKeys serves the verification keys as a bare JWK Set rather than wrapped
+// in an API response, since that's the format JWT libraries fetch.
+func (h *jwksHandler) FindKeys(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.Status(fiber.StatusOK).JSON(h.authService.JWKS())
+}
@@ -1,53 +1,49 @@
 package handler
 
 import (
-	"time"
+	"strings"
 
-	jwtware "github.com/gofiber/contrib/jwt"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/auth"
 	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/session"
 	"github.com/iqbaleff214/easynote-backend-go/user"
 )
 
-func AuthMiddleware(jwtSecret string, userService user.Service) func(c *fiber.Ctx) error {
-	return jwtware.New(jwtware.Config{
-		SigningKey: jwtware.SigningKey{Key: []byte(jwtSecret)},
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
+func AuthMiddleware(authService auth.Service, userService user.Service, sessionService session.Service) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		tokenString, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || tokenString == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(
-				helper.APIResponse("Invalid or expired JWT", "error", fiber.StatusUnauthorized, nil),
+				helper.APIResponse("Missing or malformed JWT", "error", fiber.StatusUnauthorized, nil),
 			)
-		},
-		SuccessHandler: func(c *fiber.Ctx) error {
-			t := c.Locals("user").(*jwt.Token)
-			claims := t.Claims.(jwt.MapClaims)
+		}
 
-			// Check if token has expired date
-			expiredAt, err := time.Parse(time.RFC822, claims["expired_at"].(string))
-			if err != nil {
-				return c.Status(fiber.StatusUnauthorized).JSON(
-					helper.APIResponse("Invalid or expired JWT", "error", fiber.StatusUnauthorized, nil),
-				)
-			}
+		// Signature, issuer, audience and expiry are all checked here
+		claims, err := authService.ParseToken(tokenString)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(
+				helper.APIResponse("Invalid or expired JWT", "error", fiber.StatusUnauthorized, nil),
+			)
+		}
 
-			// Check if token has expired
-			if time.Now().After(expiredAt) {
-				return c.Status(fiber.StatusUnauthorized).JSON(
-					helper.APIResponse("Expired JWT", "error", fiber.StatusUnauthorized, nil),
-				)
-			}
+		// Tokens stay valid only as long as the session they were issued for
+		currentSession, err := sessionService.FindSession(claims.UserID, claims.SessionID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(
+				helper.APIResponse("Session has been revoked", "error", fiber.StatusUnauthorized, nil),
+			)
+		}
+		c.Locals("currentSession", currentSession)
 
-			// TODO: is it necessary?
-			userID := int(claims["user_id"].(float64))
-			user, err := userService.GetUserByID(userID)
-			if err != nil {
-				return c.Status(fiber.StatusUnauthorized).JSON(
-					helper.APIResponse("User not found", "error", fiber.StatusUnauthorized, nil),
-				)
-			}
-			c.Locals("currentUser", user)
+		user, err := userService.GetUserByID(claims.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(
+				helper.APIResponse("User not found", "error", fiber.StatusUnauthorized, nil),
+			)
+		}
+		c.Locals("currentUser", user)
 
-			return c.Next()
-		},
-	})
+		return c.Next()
+	}
 }
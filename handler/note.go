@@ -1,36 +1,45 @@
 package handler
 
 import (
-	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/attachment"
 	"github.com/iqbaleff214/easynote-backend-go/helper"
 	"github.com/iqbaleff214/easynote-backend-go/note"
 	"github.com/iqbaleff214/easynote-backend-go/user"
 )
 
 type noteHandler struct {
-	noteService note.Service
+	noteService       note.Service
+	attachmentService attachment.Service
 }
 
-func NewNoteHandler(noteService note.Service) *noteHandler {
-	return &noteHandler{noteService}
+func NewNoteHandler(noteService note.Service, attachmentService attachment.Service) *noteHandler {
+	return &noteHandler{noteService, attachmentService}
 }
 
 func (h *noteHandler) FindPublicNotes(c *fiber.Ctx) error {
 	search := c.Query("q")
 
-	notes, err := h.noteService.PublicNotes(search)
+	page, err := helper.NewPage(c.QueryInt("limit"), c.Query("cursor"), c.Query("sort"), c.Query("direction"), note.Sorts)
 	if err != nil {
-		log.Println(err)
-		return c.Status(fiber.StatusBadRequest).JSON(
-			helper.APIResponse("Cannot fetch notes", "error", fiber.StatusBadRequest, nil),
-		)
+		return err
+	}
+
+	notes, pagination, err := h.noteService.PublicNotes(search, page)
+	if err != nil {
+		return err
+	}
+
+	for i := range notes {
+		if notes[i], err = note.RenderNote(notes[i], c.Query("render")); err != nil {
+			return err
+		}
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
-		helper.APIResponse("Successfully fetched public notes", "success", fiber.StatusOK, note.FormatPublicNotes(notes)),
+		helper.APIPaginatedResponse("Successfully fetched public notes", "success", fiber.StatusOK, note.FormatPublicNotes(notes), pagination),
 	)
 }
 
@@ -38,17 +47,20 @@ func (h *noteHandler) FindNotes(c *fiber.Ctx) error {
 	search := c.Query("q")
 	folderID, _ := strconv.Atoi(c.Query("folder_id"))
 
+	page, err := helper.NewPage(c.QueryInt("limit"), c.Query("cursor"), c.Query("sort"), c.Query("direction"), note.Sorts)
+	if err != nil {
+		return err
+	}
+
 	currentUser := c.Locals("currentUser").(user.User)
 
-	notes, err := h.noteService.FindNotes(currentUser.ID, folderID, search)
+	notes, pagination, err := h.noteService.FindNotes(currentUser.ID, folderID, search, page)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(
-			helper.APIResponse("Cannot fetch notes", "error", fiber.StatusBadRequest, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
-		helper.APIResponse("Successfully fetched notes", "success", fiber.StatusOK, note.FormatNotes(notes)),
+		helper.APIPaginatedResponse("Successfully fetched notes", "success", fiber.StatusOK, note.FormatNotes(notes), pagination),
 	)
 }
 
@@ -64,13 +76,21 @@ func (h *noteHandler) FindNote(c *fiber.Ctx) error {
 
 	fetchedNote, err := h.noteService.FindNote(currentUser.ID, noteID)
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot fetch the note", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
+	}
+
+	fetchedNote, err = note.RenderNote(fetchedNote, c.Query("render"))
+	if err != nil {
+		return err
+	}
+
+	attachments, err := h.attachmentService.FindAttachments(currentUser.ID, noteID)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
-		helper.APIResponse("Successfully fetched the note", "success", fiber.StatusOK, note.FormatNote(fetchedNote)),
+		helper.APIResponse("Successfully fetched the note", "success", fiber.StatusOK, attachment.FormatNote(fetchedNote, attachments)),
 	)
 }
 
@@ -84,14 +104,15 @@ func (h *noteHandler) CreateNote(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	currentUser := c.Locals("currentUser").(user.User)
 
 	newNote, err := h.noteService.CreateNote(input, currentUser.ID)
 	if err != nil {
-		log.Println(err)
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot create new note", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -109,6 +130,10 @@ func (h *noteHandler) UpdateNote(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	noteID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(
@@ -120,9 +145,7 @@ func (h *noteHandler) UpdateNote(c *fiber.Ctx) error {
 
 	updatedNote, err := h.noteService.UpdateNote(input, currentUser.ID, noteID)
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot update the note", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
@@ -130,6 +153,39 @@ func (h *noteHandler) UpdateNote(c *fiber.Ctx) error {
 	)
 }
 
+func (h *noteHandler) UpdateNoteTags(c *fiber.Ctx) error {
+
+	var input note.UpdateNoteTagsInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	updatedNote, err := h.noteService.UpdateNoteTags(input, currentUser.ID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully updated the note's tags", "success", fiber.StatusOK, note.FormatNote(updatedNote)),
+	)
+}
+
 func (h *noteHandler) DeleteNote(c *fiber.Ctx) error {
 	noteID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -141,12 +197,84 @@ func (h *noteHandler) DeleteNote(c *fiber.Ctx) error {
 	currentUser := c.Locals("currentUser").(user.User)
 
 	if err := h.noteService.DeleteNote(currentUser.ID, noteID); err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot delete the note", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
 		helper.APIResponse("Successfully deleted the note", "success", fiber.StatusOK, nil),
 	)
 }
+
+func (h *noteHandler) FindRevisions(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	revisions, err := h.noteService.FindRevisions(currentUser.ID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the note's revisions", "success", fiber.StatusOK, note.FormatRevisions(revisions)),
+	)
+}
+
+func (h *noteHandler) FindRevision(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	revisionID, err := strconv.Atoi(c.Params("rev"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your revision id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	revision, diff, err := h.noteService.FindRevision(currentUser.ID, noteID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the revision", "success", fiber.StatusOK, note.FormatRevisionDetail(revision, diff)),
+	)
+}
+
+func (h *noteHandler) RestoreRevision(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	revisionID, err := strconv.Atoi(c.Params("rev"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your revision id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	restoredNote, err := h.noteService.RestoreRevision(currentUser.ID, noteID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully restored the revision", "success", fiber.StatusOK, note.FormatNote(restoredNote)),
+	)
+}
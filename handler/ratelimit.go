@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+)
+
+// RateLimit allows Max requests per Window. A zero Max turns the limit off.
+// With FailedOnly, only requests answered with an error count, which suits
+// routes where legitimate clients never fail repeatedly. The status is read
+// right after the handler returns, so handlers behind such a limit have to
+// answer their errors with ErrorHandler rather than return them.
+type RateLimit struct {
+	Max        int
+	Window     time.Duration
+	FailedOnly bool
+}
+
+// RateLimiter limits requests sharing the same key, which by default is the
+// client IP. Every limiter keeps its own counters, so each route can have its
+// own budget.
+func RateLimiter(limit RateLimit, key func(c *fiber.Ctx) string) fiber.Handler {
+	if limit.Max <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	if key == nil {
+		key = func(c *fiber.Ctx) string {
+			return c.IP()
+		}
+	}
+
+	return limiter.New(limiter.Config{
+		Max:                    limit.Max,
+		Expiration:             limit.Window,
+		KeyGenerator:           key,
+		LimiterMiddleware:      limiter.SlidingWindow{},
+		SkipSuccessfulRequests: limit.FailedOnly,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(
+				helper.APIErrorResponse("Too many requests, please try again later", fiber.StatusTooManyRequests, "too_many_requests"),
+			)
+		},
+	})
+}
+
+// LoginEmailKey keys a limiter by the email being logged into, so guesses
+// spread over many IPs still share one budget per account. Requests without
+// one fall back to the client IP, so they don't all share a single budget.
+func LoginEmailKey(c *fiber.Ctx) string {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	if err := c.BodyParser(&input); err != nil {
+		return "ip:" + c.IP()
+	}
+
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email == "" {
+		return "ip:" + c.IP()
+	}
+
+	return "email:" + email
+}
+
+// ChallengeKey keys a limiter by the two-factor challenge, capping how many
+// codes can be tried against one. Like LoginEmailKey it falls back to the
+// client IP.
+func ChallengeKey(c *fiber.Ctx) string {
+	var input struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+
+	if err := c.BodyParser(&input); err != nil || input.ChallengeToken == "" {
+		return "ip:" + c.IP()
+	}
+
+	return "challenge:" + input.ChallengeToken
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/auth"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/session"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type sessionHandler struct {
+	sessionService session.Service
+	authService    auth.Service
+}
+
+func NewSessionHandler(sessionService session.Service, authService auth.Service) *sessionHandler {
+	return &sessionHandler{sessionService, authService}
+}
+
+func (h *sessionHandler) RefreshToken(c *fiber.Ctx) error {
+
+	var input session.RefreshTokenInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	refreshedSession, err := h.sessionService.Refresh(input, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return err
+	}
+
+	token, err := h.authService.GenerateToken(refreshedSession.UserID, refreshedSession.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully refreshed token", "success", fiber.StatusOK, session.FormatToken(token, refreshedSession)),
+	)
+}
+
+func (h *sessionHandler) Logout(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+	currentSession := c.Locals("currentSession").(session.Session)
+
+	if err := h.sessionService.RevokeSession(currentUser.ID, currentSession.ID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully logged out", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *sessionHandler) FindSessions(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+	currentSession := c.Locals("currentSession").(session.Session)
+
+	sessions, err := h.sessionService.FindSessions(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched sessions", "success", fiber.StatusOK, session.FormatSessions(sessions, currentSession.ID)),
+	)
+}
+
+func (h *sessionHandler) RevokeSession(c *fiber.Ctx) error {
+	sessionID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your session id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.sessionService.RevokeSession(currentUser.ID, sessionID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully revoked the session", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *sessionHandler) RevokeOtherSessions(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+	currentSession := c.Locals("currentSession").(session.Session)
+
+	if err := h.sessionService.RevokeOtherSessions(currentUser.ID, currentSession.ID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully revoked the other sessions", "success", fiber.StatusOK, nil),
+	)
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type shareHandler struct {
+	noteService note.Service
+	userService user.Service
+}
+
+func NewShareHandler(noteService note.Service, userService user.Service) *shareHandler {
+	return &shareHandler{noteService, userService}
+}
+
+func (h *shareHandler) FindSharedNotes(c *fiber.Ctx) error {
+	page, err := helper.NewPage(c.QueryInt("limit"), c.Query("cursor"), c.Query("sort"), c.Query("direction"), note.Sorts)
+	if err != nil {
+		return err
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	notes, pagination, err := h.noteService.FindSharedNotes(currentUser.ID, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIPaginatedResponse("Successfully fetched notes shared with you", "success", fiber.StatusOK, note.FormatNotes(notes), pagination),
+	)
+}
+
+func (h *shareHandler) FindNoteShares(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	shares, err := h.noteService.FindNoteShares(currentUser.ID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the note's shares", "success", fiber.StatusOK, note.FormatShares(shares)),
+	)
+}
+
+func (h *shareHandler) ShareNote(c *fiber.Ctx) error {
+
+	var input note.ShareInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	// An unknown email shares with nobody, answered the same as a known one
+	grantee, err := h.userService.GetUserByEmail(input.Email)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		return err
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	share, err := h.noteService.ShareNote(input, currentUser.ID, noteID, grantee.ID)
+	if err != nil {
+		return err
+	}
+	share.UserEmail = input.Email
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully shared the note", "success", fiber.StatusOK, note.FormatShareRequest(share)),
+	)
+}
+
+func (h *shareHandler) UnshareNote(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	granteeID, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your user id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.noteService.UnshareNote(currentUser.ID, noteID, granteeID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully stopped sharing the note", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *shareHandler) FindFolderShares(c *fiber.Ctx) error {
+	folderID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your folder id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	shares, err := h.noteService.FindFolderShares(currentUser.ID, folderID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the folder's shares", "success", fiber.StatusOK, note.FormatShares(shares)),
+	)
+}
+
+func (h *shareHandler) ShareFolder(c *fiber.Ctx) error {
+
+	var input note.ShareInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	folderID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your folder id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	// An unknown email shares with nobody, answered the same as a known one
+	grantee, err := h.userService.GetUserByEmail(input.Email)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		return err
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	share, err := h.noteService.ShareFolder(input, currentUser.ID, folderID, grantee.ID)
+	if err != nil {
+		return err
+	}
+	share.UserEmail = input.Email
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully shared the folder", "success", fiber.StatusOK, note.FormatShareRequest(share)),
+	)
+}
+
+func (h *shareHandler) UnshareFolder(c *fiber.Ctx) error {
+	folderID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your folder id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	granteeID, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your user id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.noteService.UnshareFolder(currentUser.ID, folderID, granteeID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully stopped sharing the folder", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *shareHandler) FindShareLinks(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	links, err := h.noteService.FindShareLinks(currentUser.ID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the note's share links", "success", fiber.StatusOK, note.FormatShareLinks(links)),
+	)
+}
+
+func (h *shareHandler) CreateShareLink(c *fiber.Ctx) error {
+
+	var input note.CreateShareLinkInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	link, err := h.noteService.CreateShareLink(input, currentUser.ID, noteID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully created a share link for the note", "success", fiber.StatusOK, note.FormatShareLink(link)),
+	)
+}
+
+func (h *shareHandler) RevokeShareLink(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your note id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	linkID, err := strconv.Atoi(c.Params("link_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your share link id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.noteService.RevokeShareLink(currentUser.ID, noteID, linkID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully revoked the share link", "success", fiber.StatusOK, nil),
+	)
+}
+
+// FindLinkedNote answers its errors itself. Its limiter only counts failed
+// requests and checks the status as soon as the handler returns, before
+// ErrorHandler would get to set it.
+func (h *shareHandler) FindLinkedNote(c *fiber.Ctx) error {
+	if err := h.findLinkedNote(c); err != nil {
+		return ErrorHandler(c, err)
+	}
+
+	return nil
+}
+
+func (h *shareHandler) findLinkedNote(c *fiber.Ctx) error {
+	password := c.Get("X-Share-Password")
+
+	fetchedNote, err := h.noteService.FindNoteByShareLink(c.Params("token"), password)
+	if err != nil {
+		return err
+	}
+
+	fetchedNote, err = note.RenderNote(fetchedNote, c.Query("render"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the shared note", "success", fiber.StatusOK, note.FormatPublicNote(fetchedNote)),
+	)
+}
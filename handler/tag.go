@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type tagHandler struct {
+	noteService note.Service
+}
+
+func NewTagHandler(noteService note.Service) *tagHandler {
+	return &tagHandler{noteService}
+}
+
+func (h *tagHandler) FindTags(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	tags, err := h.noteService.FindTags(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched tags", "success", fiber.StatusOK, note.FormatTags(tags)),
+	)
+}
+
+func (h *tagHandler) FindTagNotes(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your tag name", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	page, err := helper.NewPage(c.QueryInt("limit"), c.Query("cursor"), c.Query("sort"), c.Query("direction"), note.Sorts)
+	if err != nil {
+		return err
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	notes, pagination, err := h.noteService.FindNotesByTag(currentUser.ID, name, page)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIPaginatedResponse("Successfully fetched the tag's notes", "success", fiber.StatusOK, note.FormatNotes(notes), pagination),
+	)
+}
+
+func (h *tagHandler) RenameTag(c *fiber.Ctx) error {
+
+	var input note.RenameTagInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your tag name", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	renamedTag, err := h.noteService.RenameTag(currentUser.ID, name, input)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully renamed the tag", "success", fiber.StatusOK, note.FormatTag(renamedTag)),
+	)
+}
+
+func (h *tagHandler) MergeTag(c *fiber.Ctx) error {
+
+	var input note.MergeTagInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your tag name", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	mergedTag, err := h.noteService.MergeTag(currentUser.ID, name, input)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully merged the tags", "success", fiber.StatusOK, note.FormatTag(mergedTag)),
+	)
+}
+
+func (h *tagHandler) DeleteTag(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("name"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your tag name", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.noteService.DeleteTag(currentUser.ID, name); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully deleted the tag", "success", fiber.StatusOK, nil),
+	)
+}
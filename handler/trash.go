@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/iqbaleff214/easynote-backend-go/folder"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+type trashHandler struct {
+	noteService   note.Service
+	folderService folder.Service
+}
+
+func NewTrashHandler(noteService note.Service, folderService folder.Service) *trashHandler {
+	return &trashHandler{noteService, folderService}
+}
+
+func (h *trashHandler) FindTrash(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	notes, err := h.noteService.FindTrashedNotes(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	folders, err := h.folderService.FindTrashedFolders(currentUser.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Successfully fetched the trash", "success", fiber.StatusOK, fiber.Map{
+			"notes":   note.FormatTrashedNotes(notes),
+			"folders": folder.FormatTrashedFolders(folders),
+		}),
+	)
+}
+
+func (h *trashHandler) Restore(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your item id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	switch c.Params("type") {
+	case "notes":
+		restoredNote, err := h.noteService.RestoreNote(currentUser.ID, id)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusOK).JSON(
+			helper.APIResponse("Successfully restored the note", "success", fiber.StatusOK, note.FormatNote(restoredNote)),
+		)
+	case "folders":
+		restoredFolder, err := h.folderService.RestoreFolder(currentUser.ID, id)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusOK).JSON(
+			helper.APIResponse("Successfully restored the folder", "success", fiber.StatusOK, folder.FormatFolder(restoredFolder)),
+		)
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(
+		helper.APIResponse("Item type must be either notes or folders", "error", fiber.StatusBadRequest, nil),
+	)
+}
+
+func (h *trashHandler) Purge(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with your item id", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	switch c.Params("type") {
+	case "notes":
+		if err := h.noteService.PurgeNote(currentUser.ID, id); err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusOK).JSON(
+			helper.APIResponse("Successfully permanently deleted the note", "success", fiber.StatusOK, nil),
+		)
+	case "folders":
+		if err := h.folderService.PurgeFolder(currentUser.ID, id); err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusOK).JSON(
+			helper.APIResponse("Successfully permanently deleted the folder", "success", fiber.StatusOK, nil),
+		)
+	}
+
+	return c.Status(fiber.StatusBadRequest).JSON(
+		helper.APIResponse("Item type must be either notes or folders", "error", fiber.StatusBadRequest, nil),
+	)
+}
@@ -4,16 +4,18 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/iqbaleff214/easynote-backend-go/auth"
 	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/session"
 	"github.com/iqbaleff214/easynote-backend-go/user"
 )
 
 type userHandler struct {
-	userService user.Service
-	authService auth.Service
+	userService    user.Service
+	authService    auth.Service
+	sessionService session.Service
 }
 
-func NewUserHandler(userService user.Service, authService auth.Service) *userHandler {
-	return &userHandler{userService, authService}
+func NewUserHandler(userService user.Service, authService auth.Service, sessionService session.Service) *userHandler {
+	return &userHandler{userService, authService, sessionService}
 }
 
 func (h *userHandler) RegisterUser(c *fiber.Ctx) error {
@@ -26,21 +28,26 @@ func (h *userHandler) RegisterUser(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	newUser, err := h.userService.RegisterUser(input)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(
-			helper.APIResponse("Cannot create new user", "error", fiber.StatusInternalServerError, nil),
-		)
+		return err
 	}
 
-	token, err := h.authService.GenerateToken(newUser.ID)
+	newSession, err := h.sessionService.StartSession(newUser.ID, c.Get(fiber.HeaderUserAgent), c.IP())
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot generate token for new user", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
-	response := helper.APIResponse("New user has been registered", "success", fiber.StatusCreated, user.FormatUser(newUser, token))
+	token, err := h.authService.GenerateToken(newUser.ID, newSession.ID)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse("New user has been registered", "success", fiber.StatusCreated, user.FormatAuthenticatedUser(newUser, token, newSession.RefreshToken))
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
@@ -55,21 +62,67 @@ func (h *userHandler) Login(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	loggedUser, err := h.userService.Login(input)
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse(err.Error(), "error", fiber.StatusUnprocessableEntity, nil),
+		return err
+	}
+
+	// With two-factor on, the password only earns a challenge to be
+	// completed at /login/2fa
+	if loggedUser.TwoFactorEnabled() {
+		challengeToken, err := h.userService.StartTwoFactorChallenge(loggedUser)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusOK).JSON(
+			helper.APIResponse("Two-factor code required", "success", fiber.StatusOK, user.FormatTwoFactorChallenge(challengeToken)),
 		)
 	}
 
-	token, err := h.authService.GenerateToken(loggedUser.ID)
-	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot generate token for current user", "error", fiber.StatusUnprocessableEntity, nil),
+	return h.logIn(c, loggedUser)
+}
+
+func (h *userHandler) LoginWithTwoFactor(c *fiber.Ctx) error {
+
+	var input user.TwoFactorLoginInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
 		)
 	}
 
-	response := helper.APIResponse("Successfully logged in", "success", fiber.StatusOK, user.FormatUser(loggedUser, token))
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	loggedUser, err := h.userService.LoginWithTwoFactor(input)
+	if err != nil {
+		return err
+	}
+
+	return h.logIn(c, loggedUser)
+}
+
+// logIn starts a session for the authenticated user and responds with its
+// tokens.
+func (h *userHandler) logIn(c *fiber.Ctx, loggedUser user.User) error {
+	newSession, err := h.sessionService.StartSession(loggedUser.ID, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return err
+	}
+
+	token, err := h.authService.GenerateToken(loggedUser.ID, newSession.ID)
+	if err != nil {
+		return err
+	}
+
+	response := helper.APIResponse("Successfully logged in", "success", fiber.StatusOK, user.FormatAuthenticatedUser(loggedUser, token, newSession.RefreshToken))
 
 	return c.Status(fiber.StatusOK).JSON(response)
 }
@@ -92,16 +145,170 @@ func (h *userHandler) UpdateUser(c *fiber.Ctx) error {
 		)
 	}
 
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
 	currentUser := c.Locals("currentUser").(user.User)
 
 	updatedUser, err := h.userService.UpdateUser(input, currentUser)
 	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(
-			helper.APIResponse("Cannot update current user's profile", "error", fiber.StatusUnprocessableEntity, nil),
-		)
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(
 		helper.APIResponse("Successfully updated current user's profile", "success", fiber.StatusOK, user.FormatUser(updatedUser, "")),
 	)
 }
+
+func (h *userHandler) ForgotPassword(c *fiber.Ctx) error {
+
+	var input user.ForgotPasswordInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	if err := h.userService.ForgotPassword(input); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("If the email is registered, a password reset link has been sent to it", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *userHandler) ResetPassword(c *fiber.Ctx) error {
+
+	var input user.ResetPasswordInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	resetUser, err := h.userService.ResetPassword(input)
+	if err != nil {
+		return err
+	}
+
+	// Whoever knew the old password shouldn't stay logged in
+	if err := h.sessionService.RevokeOtherSessions(resetUser.ID, 0); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Password has been reset, please log in again", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *userHandler) VerifyEmail(c *fiber.Ctx) error {
+
+	var input user.VerifyEmailInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	verifiedUser, err := h.userService.VerifyEmail(input)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Email has been verified", "success", fiber.StatusOK, user.FormatUser(verifiedUser, "")),
+	)
+}
+
+func (h *userHandler) ResendVerification(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.userService.SendVerification(currentUser); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Verification link has been sent", "success", fiber.StatusOK, nil),
+	)
+}
+
+func (h *userHandler) EnableTwoFactor(c *fiber.Ctx) error {
+	currentUser := c.Locals("currentUser").(user.User)
+
+	updatedUser, provisioningURI, err := h.userService.EnableTwoFactor(currentUser)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Scan the provisioning URI and confirm with a code", "success", fiber.StatusOK, user.FormatTwoFactorSetup(updatedUser, provisioningURI)),
+	)
+}
+
+func (h *userHandler) ConfirmTwoFactor(c *fiber.Ctx) error {
+
+	var input user.ConfirmTwoFactorInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	codes, err := h.userService.ConfirmTwoFactor(input, currentUser)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Two-factor authentication has been enabled, keep the recovery codes somewhere safe", "success", fiber.StatusOK, user.FormatRecoveryCodes(codes)),
+	)
+}
+
+func (h *userHandler) DisableTwoFactor(c *fiber.Ctx) error {
+
+	var input user.DisableTwoFactorInput
+
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(
+			helper.APIResponse("There's something wrong with request body", "error", fiber.StatusBadRequest, nil),
+		)
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(helper.APIValidationResponse(errs))
+	}
+
+	currentUser := c.Locals("currentUser").(user.User)
+
+	if err := h.userService.DisableTwoFactor(input, currentUser); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(
+		helper.APIResponse("Two-factor authentication has been disabled", "success", fiber.StatusOK, nil),
+	)
+}
@@ -0,0 +1,65 @@
+package helper
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Kinds of failure the API tells apart. Domain errors wrap one of them, so
+// the error handler can pick a status code without knowing every domain.
+var (
+	ErrValidation      = errors.New("validation failed")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrTooLarge        = errors.New("too large")
+)
+
+var (
+	ErrNoSession      = NewError(ErrUnauthorized, "no_session", "no user available on this session")
+	ErrInvalidCursor  = NewError(ErrValidation, "invalid_cursor", "invalid cursor")
+	ErrCursorMismatch = NewError(ErrValidation, "cursor_mismatch", "cursor doesn't match the requested sort")
+	ErrInvalidSort    = NewError(ErrValidation, "invalid_sort", "unsupported sort")
+	ErrInvalidOrder   = NewError(ErrValidation, "invalid_direction", "direction must be either asc or desc")
+)
+
+// Error is a domain error with a machine readable code, such as
+// note_not_found, that clients can branch on instead of the message.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+func NewError(kind error, code, message string) *Error {
+	return &Error{kind, code, message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NoRows swaps sql.ErrNoRows for the domain's not found error and leaves any
+// other error as it is.
+func NoRows(err, notFound error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound
+	}
+
+	return err
+}
+
+// IsDuplicateEntry tells whether err is MySQL refusing a row that breaks a
+// unique key.
+func IsDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
@@ -1,14 +1,17 @@
 package helper
 
 type Response struct {
-	Meta Meta `json:"meta"`
-	Data any  `json:"data,omitempty"`
+	Meta   Meta             `json:"meta"`
+	Data   any              `json:"data,omitempty"`
+	Errors ValidationErrors `json:"errors,omitempty"`
 }
 
 type Meta struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-	Status  string `json:"status"`
+	Message    string      `json:"message"`
+	Code       int         `json:"code"`
+	Status     string      `json:"status"`
+	ErrorCode  string      `json:"error_code,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
 }
 
 func APIResponse(message, status string, code int, data any) Response {
@@ -21,3 +24,24 @@ func APIResponse(message, status string, code int, data any) Response {
 		Data: data,
 	}
 }
+
+func APIPaginatedResponse(message, status string, code int, data any, pagination Pagination) Response {
+	response := APIResponse(message, status, code, data)
+	response.Meta.Pagination = &pagination
+
+	return response
+}
+
+func APIErrorResponse(message string, code int, errorCode string) Response {
+	response := APIResponse(message, "error", code, nil)
+	response.Meta.ErrorCode = errorCode
+
+	return response
+}
+
+func APIValidationResponse(errors ValidationErrors) Response {
+	response := APIErrorResponse("The given data was invalid", 422, "validation_failed")
+	response.Errors = errors
+
+	return response
+}
@@ -0,0 +1,169 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Page describes which slice of a list endpoint's results to return.
+type Page struct {
+	Limit  int
+	Sort   string
+	Desc   bool
+	Cursor Cursor
+}
+
+// Cursor marks the last row of the previous page. Column sorts seek past
+// Value and ID, while sorts on computed values fall back to Offset.
+type Cursor struct {
+	Sort   string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Value  string `json:"v,omitempty"`
+	ID     int    `json:"id,omitempty"`
+	Offset int    `json:"o,omitempty"`
+}
+
+type Pagination struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// NewPage validates the list query parameters against the sorts allowed by
+// the endpoint. An empty sort keeps the endpoint's own default.
+func NewPage(limit int, cursor, sort, direction string, sorts []string) (Page, error) {
+	var page Page
+
+	page.Limit = limit
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if page.Limit > MaxPageLimit {
+		page.Limit = MaxPageLimit
+	}
+
+	if sort != "" {
+		allowed := false
+		for _, s := range sorts {
+			if s == sort {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return page, NewError(ErrValidation, "invalid_sort", fmt.Sprintf("sort must be one of %v", sorts))
+		}
+	}
+	page.Sort = sort
+
+	switch direction {
+	case "", "desc":
+		page.Desc = true
+	case "asc":
+		page.Desc = false
+	default:
+		return page, ErrInvalidOrder
+	}
+
+	if cursor != "" {
+		decoded, err := DecodeCursor(cursor)
+		if err != nil {
+			return page, err
+		}
+
+		page.Cursor = decoded
+	}
+
+	return page, nil
+}
+
+// WithDefaultSort fills in the sort when the client didn't pick one, and
+// checks that the cursor belongs to the same ordering.
+func (p Page) WithDefaultSort(sort string) (Page, error) {
+	if p.Sort == "" {
+		p.Sort = sort
+	}
+
+	if p.Cursor != (Cursor{}) && (p.Cursor.Sort != p.Sort || p.Cursor.Desc != p.Desc) {
+		return p, ErrCursorMismatch
+	}
+
+	return p, nil
+}
+
+// Keyset returns the condition that seeks past the cursor on column, with
+// idColumn as tie-breaker, along with the matching ORDER BY and LIMIT.
+// One extra row is requested so callers can tell whether there's more.
+func (p Page) Keyset(column, idColumn string, value any) (condition string, args []any, orderLimit string) {
+	operator, direction := ">", "ASC"
+	if p.Desc {
+		operator, direction = "<", "DESC"
+	}
+
+	orderLimit = fmt.Sprintf("ORDER BY %s %s, %s %s LIMIT %d", column, direction, idColumn, direction, p.Limit+1)
+
+	if p.Cursor == (Cursor{}) {
+		return "TRUE", nil, orderLimit
+	}
+
+	condition = fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", column, operator, column, idColumn, operator)
+
+	return condition, []any{value, value, p.Cursor.ID}, orderLimit
+}
+
+// OffsetLimit returns the ORDER BY and LIMIT for sorts that can't be seeked,
+// such as search relevance.
+func (p Page) OffsetLimit(orderBy string) string {
+	return fmt.Sprintf("ORDER BY %s LIMIT %d OFFSET %d", orderBy, p.Limit+1, p.Cursor.Offset)
+}
+
+// NextOffset is the cursor of the page after this one for OffsetLimit sorts.
+func (p Page) NextOffset() Cursor {
+	return Cursor{Offset: p.Cursor.Offset + p.Limit}
+}
+
+// Next builds the pagination meta for a page of rowCount rows fetched with
+// Keyset or OffsetLimit, where last is the cursor of the final row kept.
+func (p Page) Next(rowCount int, last Cursor) Pagination {
+	var pagination Pagination
+
+	if rowCount <= p.Limit {
+		return pagination
+	}
+
+	last.Sort = p.Sort
+	last.Desc = p.Desc
+
+	pagination.HasMore = true
+	pagination.NextCursor = EncodeCursor(last)
+
+	return pagination
+}
+
+func EncodeCursor(cursor Cursor) string {
+	raw, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(encoded string) (Cursor, error) {
+	var cursor Cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,206 @@
+package helper
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"empty", Cursor{}},
+		{"keyset", Cursor{Sort: "updated_at", Desc: true, Value: "2024-01-02T03:04:05Z", ID: 42}},
+		{"offset", Cursor{Sort: "relevance", Offset: 40}},
+		{"value needing escapes", Cursor{Sort: "title", Value: `"quoted" & <tagged> ✓`, ID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeCursor(EncodeCursor(tt.cursor))
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+
+			if decoded != tt.cursor {
+				t.Errorf("round trip = %#v, want %#v", decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	valid := EncodeCursor(Cursor{Sort: "title", Value: "a", ID: 1})
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"title"}`))},
+		{"truncated", valid[:len(valid)-3]},
+		{"not json", encode("title:a:1")},
+		{"wrong types", encode(`{"s":"title","id":"1"}`)},
+		{"json array", encode(`[1,2]`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.encoded); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want %v", tt.encoded, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	sorts := []string{"title", "updated_at"}
+	cursor := Cursor{Sort: "title", Desc: true, Value: "a", ID: 3}
+
+	tests := []struct {
+		name      string
+		limit     int
+		cursor    string
+		sort      string
+		direction string
+		want      Page
+		wantErr   error
+	}{
+		{"defaults", 0, "", "", "", Page{Limit: DefaultPageLimit, Desc: true}, nil},
+		{"negative limit", -5, "", "", "", Page{Limit: DefaultPageLimit, Desc: true}, nil},
+		{"limit capped", MaxPageLimit + 1, "", "", "", Page{Limit: MaxPageLimit, Desc: true}, nil},
+		{"ascending", 10, "", "title", "asc", Page{Limit: 10, Sort: "title"}, nil},
+		{"with cursor", 10, EncodeCursor(cursor), "title", "desc", Page{Limit: 10, Sort: "title", Desc: true, Cursor: cursor}, nil},
+		{"unknown sort", 10, "", "password", "", Page{}, ErrValidation},
+		{"unknown direction", 10, "", "", "sideways", Page{}, ErrInvalidOrder},
+		{"tampered cursor", 10, "%%%", "", "", Page{}, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewPage(tt.limit, tt.cursor, tt.sort, tt.direction, sorts)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("NewPage() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewPage() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPage() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageWithDefaultSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     Page
+		wantSort string
+		wantErr  error
+	}{
+		{"fills in the sort", Page{Desc: true}, "updated_at", nil},
+		{"keeps the client's sort", Page{Sort: "title"}, "title", nil},
+		{"cursor of the same ordering", Page{Sort: "title", Cursor: Cursor{Sort: "title", ID: 1}}, "title", nil},
+		{"cursor of another sort", Page{Sort: "title", Cursor: Cursor{Sort: "updated_at", ID: 1}}, "", ErrCursorMismatch},
+		{"cursor of another direction", Page{Sort: "title", Cursor: Cursor{Sort: "title", Desc: true, ID: 1}}, "", ErrCursorMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.page.WithDefaultSort("updated_at")
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithDefaultSort() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && got.Sort != tt.wantSort {
+				t.Errorf("WithDefaultSort() sort = %q, want %q", got.Sort, tt.wantSort)
+			}
+		})
+	}
+}
+
+func TestPageKeyset(t *testing.T) {
+	tests := []struct {
+		name          string
+		page          Page
+		wantCondition string
+		wantArgs      []any
+		wantOrder     string
+	}{
+		{
+			name:          "first page",
+			page:          Page{Limit: 20, Desc: true},
+			wantCondition: "TRUE",
+			wantOrder:     "ORDER BY n.title DESC, n.id DESC LIMIT 21",
+		},
+		{
+			name:          "descending after a cursor",
+			page:          Page{Limit: 20, Desc: true, Cursor: Cursor{Value: "b", ID: 7}},
+			wantCondition: "(n.title < ? OR (n.title = ? AND n.id < ?))",
+			wantArgs:      []any{"b", "b", 7},
+			wantOrder:     "ORDER BY n.title DESC, n.id DESC LIMIT 21",
+		},
+		{
+			name:          "ascending after a cursor",
+			page:          Page{Limit: 5, Cursor: Cursor{Value: "b", ID: 7}},
+			wantCondition: "(n.title > ? OR (n.title = ? AND n.id > ?))",
+			wantArgs:      []any{"b", "b", 7},
+			wantOrder:     "ORDER BY n.title ASC, n.id ASC LIMIT 6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args, order := tt.page.Keyset("n.title", "n.id", tt.page.Cursor.Value)
+
+			if condition != tt.wantCondition {
+				t.Errorf("condition = %q, want %q", condition, tt.wantCondition)
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+
+			if order != tt.wantOrder {
+				t.Errorf("order = %q, want %q", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestPageNext(t *testing.T) {
+	page := Page{Limit: 2, Sort: "title", Desc: true}
+
+	if got := page.Next(2, Cursor{Value: "b", ID: 2}); got.HasMore || got.NextCursor != "" {
+		t.Errorf("Next() on the last page = %#v, want no next page", got)
+	}
+
+	got := page.Next(3, Cursor{Value: "b", ID: 2})
+	if !got.HasMore {
+		t.Fatalf("Next() = %#v, want more", got)
+	}
+
+	next, err := DecodeCursor(got.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+
+	if want := (Cursor{Sort: "title", Desc: true, Value: "b", ID: 2}); next != want {
+		t.Errorf("next cursor = %#v, want %#v", next, want)
+	}
+}
@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidationErrors maps a field's JSON name to what's wrong with it.
+type ValidationErrors map[string]string
+
+// Validate checks a struct against the rules in its validate tags and
+// returns nil when it passes. Rules are comma separated:
+//
+//	required          the field can't be empty
+//	required_without=F  the field can't be empty when field F is
+//	email             a valid email address
+//	password          at least 8 characters with a letter and a number
+//	min=N, max=N      length of strings, item count of slices, value of numbers
+//	oneof=a b c       one of the listed values
+//	dive              the rules after it apply to every item of a slice
+//
+// Except for required and required_without, rules are skipped for empty
+// fields, so optional fields only need checking when they're set.
+func Validate(input any) ValidationErrors {
+	errs := ValidationErrors{}
+
+	value := reflect.Indirect(reflect.ValueOf(input))
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		validateField(errs, value, fieldName(field), value.Field(i), strings.Split(rules, ","))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func validateField(errs ValidationErrors, parent reflect.Value, name string, value reflect.Value, rules []string) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value = reflect.Value{}
+		} else {
+			value = value.Elem()
+		}
+	}
+
+	empty := !value.IsValid() || value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0)
+
+	for i, rule := range rules {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch rule {
+		case "required":
+			if empty {
+				errs[name] = "is required"
+				return
+			}
+			continue
+		case "required_without":
+			other := parent.FieldByName(param)
+			if empty && (!other.IsValid() || other.IsZero()) {
+				errs[name] = "is required"
+				return
+			}
+			continue
+		}
+
+		if empty {
+			return
+		}
+
+		if rule == "dive" {
+			for j := 0; j < value.Len(); j++ {
+				validateField(errs, parent, name+"."+strconv.Itoa(j), value.Index(j), rules[i+1:])
+			}
+			return
+		}
+
+		if message := check(rule, param, value); message != "" {
+			errs[name] = message
+			return
+		}
+	}
+}
+
+func check(rule, param string, value reflect.Value) string {
+	switch rule {
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address"
+		}
+	case "password":
+		password := value.String()
+		hasLetter := strings.IndexFunc(password, unicode.IsLetter) >= 0
+		hasNumber := strings.IndexFunc(password, unicode.IsDigit) >= 0
+		if utf8.RuneCountInString(password) < 8 || !hasLetter || !hasNumber {
+			return "must be at least 8 characters and contain a letter and a number"
+		}
+	case "min", "max":
+		limit, _ := strconv.Atoi(param)
+		size, unit := measure(value)
+		if rule == "min" && size < limit {
+			return fmt.Sprintf("must be at least %d%s", limit, unit)
+		}
+		if rule == "max" && size > limit {
+			return fmt.Sprintf("must be at most %d%s", limit, unit)
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		for _, option := range options {
+			if fmt.Sprint(value.Interface()) == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	}
+
+	return ""
+}
+
+func measure(value reflect.Value) (int, string) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), " characters"
+	case reflect.Slice, reflect.Map:
+		return value.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(value.Int()), ""
+	}
+
+	return 0, ""
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
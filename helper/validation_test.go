@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validationInput struct {
+	Name       string    `json:"name" validate:"required,max=5"`
+	Email      string    `json:"email" validate:"email"`
+	Password   string    `json:"password,omitempty" validate:"password"`
+	Code       string    `json:"code" validate:"required_without=Recovery"`
+	Recovery   string    `json:"recovery"`
+	Format     string    `json:"format" validate:"oneof=plain markdown"`
+	Count      int       `json:"count" validate:"min=1,max=3"`
+	Tags       []string  `json:"tags" validate:"max=2,dive,required,max=3"`
+	Labels     *[]string `json:"labels" validate:"dive,max=2"`
+	Untagged   string
+	unexported string `validate:"required"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() validationInput {
+		return validationInput{Name: "note", Code: "123456"}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(input *validationInput)
+		want   ValidationErrors
+	}{
+		{"valid", func(input *validationInput) {}, nil},
+		{"empty input", func(input *validationInput) { *input = validationInput{} }, ValidationErrors{
+			"name": "is required",
+			"code": "is required",
+		}},
+		{"required is checked before the other rules", func(input *validationInput) { input.Name = "" }, ValidationErrors{
+			"name": "is required",
+		}},
+		{"max counts characters, not bytes", func(input *validationInput) { input.Name = "ééééé" }, nil},
+		{"max of a string", func(input *validationInput) { input.Name = "toolong" }, ValidationErrors{
+			"name": "must be at most 5 characters",
+		}},
+		{"email", func(input *validationInput) { input.Email = "someone@example.com" }, nil},
+		{"email with a display name", func(input *validationInput) { input.Email = "Someone <someone@example.com>" }, ValidationErrors{
+			"email": "must be a valid email address",
+		}},
+		{"not an email", func(input *validationInput) { input.Email = "someone" }, ValidationErrors{
+			"email": "must be a valid email address",
+		}},
+		{"password", func(input *validationInput) { input.Password = "secret123" }, nil},
+		{"password without a number", func(input *validationInput) { input.Password = "secretpassword" }, ValidationErrors{
+			"password": "must be at least 8 characters and contain a letter and a number",
+		}},
+		{"short password", func(input *validationInput) { input.Password = "abc1" }, ValidationErrors{
+			"password": "must be at least 8 characters and contain a letter and a number",
+		}},
+		{"required_without satisfied by the other field", func(input *validationInput) {
+			input.Code = ""
+			input.Recovery = "abcd-efgh"
+		}, nil},
+		{"oneof", func(input *validationInput) { input.Format = "markdown" }, nil},
+		{"not oneof", func(input *validationInput) { input.Format = "html" }, ValidationErrors{
+			"format": "must be one of: plain, markdown",
+		}},
+		{"min of a number", func(input *validationInput) { input.Count = -1 }, ValidationErrors{
+			"count": "must be at least 1",
+		}},
+		{"max of a number", func(input *validationInput) { input.Count = 4 }, ValidationErrors{
+			"count": "must be at most 3",
+		}},
+		{"max of a slice", func(input *validationInput) { input.Tags = []string{"a", "b", "c"} }, ValidationErrors{
+			"tags": "must be at most 2 items",
+		}},
+		{"dive into items", func(input *validationInput) { input.Tags = []string{"ok", ""} }, ValidationErrors{
+			"tags.1": "is required",
+		}},
+		{"dive into item length", func(input *validationInput) { input.Tags = []string{"long", "ok"} }, ValidationErrors{
+			"tags.0": "must be at most 3 characters",
+		}},
+		{"nil pointer is empty", func(input *validationInput) { input.Labels = nil }, nil},
+		{"pointer to a slice", func(input *validationInput) { input.Labels = &[]string{"abc"} }, ValidationErrors{
+			"labels.0": "must be at most 2 characters",
+		}},
+		{"untagged and unexported fields are ignored", func(input *validationInput) {
+			input.Untagged = strings.Repeat("x", 100)
+			input.unexported = ""
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid()
+			tt.modify(&input)
+
+			if got := Validate(input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsPointers(t *testing.T) {
+	input := &validationInput{Code: "123456"}
+
+	want := ValidationErrors{"name": "is required"}
+	if got := Validate(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %v, want %v", got, want)
+	}
+}
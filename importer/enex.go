@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Tags    []string `xml:"tag"`
+}
+
+// parseENEX reads an Evernote export. Evernote has notebooks but doesn't
+// export them, so every note lands in the root. The ENML content is kept as
+// HTML, which rendering sanitizes like any other HTML note.
+func parseENEX(data []byte) (Batch, error) {
+	var batch Batch
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// ENEX content is mostly CDATA, but titles may use HTML entities
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	found := false
+	index := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return batch, errors.New("file isn't a valid ENEX export")
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "en-export":
+			found = true
+		case "note":
+			index++
+			source := fmt.Sprintf("note %d", index)
+
+			var n enexNote
+			if err := decoder.DecodeElement(&n, &start); err != nil {
+				batch.Errors = append(batch.Errors, ItemError{Item: source, Error: "note isn't valid XML"})
+				return batch, nil
+			}
+
+			if n.Title != "" {
+				source = fmt.Sprintf("%s (%s)", source, n.Title)
+			}
+
+			batch.Notes = append(batch.Notes, NoteItem{
+				Source:  source,
+				Title:   n.Title,
+				Content: enmlBody(n.Content),
+				Format:  note.FormatHTML,
+				Tags:    n.Tags,
+			})
+		}
+	}
+
+	if !found {
+		return batch, errors.New("file isn't a valid ENEX export")
+	}
+
+	return batch, nil
+}
+
+// enmlBody returns what's inside the en-note element, dropping the XML
+// declaration and doctype that wrap every ENML document.
+func enmlBody(content string) string {
+	start := strings.Index(content, "<en-note")
+	if start < 0 {
+		return strings.TrimSpace(content)
+	}
+
+	open := strings.IndexByte(content[start:], '>')
+	if open < 0 {
+		return ""
+	}
+
+	body := content[start+open+1:]
+	if end := strings.LastIndex(body, "</en-note>"); end >= 0 {
+		body = body[:end]
+	}
+
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,63 @@
+package importer
+
+import "time"
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatENEX     = "enex"
+	FormatJSON     = "json"
+)
+
+type Job struct {
+	ID         int
+	UserID     int
+	Format     string
+	Filename   string
+	Status     string
+	Total      int
+	Imported   int
+	Failed     int
+	Errors     []ItemError
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ItemError tells why one folder or note of an upload couldn't be imported.
+type ItemError struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// Batch is what was read out of an upload before anything gets created.
+// Folders refer to their parent, and notes to their folder, by a key that
+// only means something within the batch.
+type Batch struct {
+	Folders []FolderItem
+	Notes   []NoteItem
+	Errors  []ItemError
+}
+
+type FolderItem struct {
+	Key       string
+	ParentKey string
+	Name      string
+}
+
+type NoteItem struct {
+	Source    string
+	FolderKey string
+	Title     string
+	Content   string
+	Format    string
+	Tags      []string
+	IsPublic  bool
+}
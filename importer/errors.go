@@ -0,0 +1,9 @@
+package importer
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var (
+	ErrNotFound          = helper.NewError(helper.ErrNotFound, "import_not_found", "import doesn't exist")
+	ErrUnsupportedFormat = helper.NewError(helper.ErrValidation, "unsupported_import_format", "format must be markdown, enex or json, or the file must end in .zip, .enex or .json")
+	ErrEmptyFile         = helper.NewError(helper.ErrValidation, "empty_import_file", "the uploaded file is empty")
+)
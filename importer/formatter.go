@@ -0,0 +1,50 @@
+package importer
+
+import "time"
+
+type JobFormatter struct {
+	ID         int                  `json:"id"`
+	Format     string               `json:"format"`
+	Filename   string               `json:"filename"`
+	Status     string               `json:"status"`
+	Total      int                  `json:"total"`
+	Imported   int                  `json:"imported"`
+	Failed     int                  `json:"failed"`
+	Errors     []ItemErrorFormatter `json:"errors"`
+	Error      string               `json:"error,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+	FinishedAt *time.Time           `json:"finished_at"`
+}
+
+type ItemErrorFormatter struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+func FormatJob(job Job) JobFormatter {
+	formatter := JobFormatter{
+		ID:        job.ID,
+		Format:    job.Format,
+		Filename:  job.Filename,
+		Status:    job.Status,
+		Total:     job.Total,
+		Imported:  job.Imported,
+		Failed:    job.Failed,
+		Errors:    []ItemErrorFormatter{},
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+
+	for _, itemError := range job.Errors {
+		formatter.Errors = append(formatter.Errors, ItemErrorFormatter{Item: itemError.Item, Error: itemError.Error})
+	}
+
+	if !job.FinishedAt.IsZero() {
+		finishedAt := job.FinishedAt
+		formatter.FinishedAt = &finishedAt
+	}
+
+	return formatter
+}
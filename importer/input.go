@@ -0,0 +1,5 @@
+package importer
+
+type ImportInput struct {
+	Format string `json:"format" form:"format" validate:"oneof=markdown enex json"`
+}
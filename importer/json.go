@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/iqbaleff214/easynote-backend-go/export"
+)
+
+// parseJSON reads a JSON export back, with the folders keyed by the ids they
+// had in the exporting account.
+func parseJSON(data []byte) (Batch, error) {
+	var batch Batch
+	var document export.Document
+
+	if err := json.Unmarshal(data, &document); err != nil {
+		return batch, errors.New("file isn't a valid JSON export")
+	}
+
+	if document.Version != export.DocumentVersion {
+		return batch, fmt.Errorf("export version %d isn't supported", document.Version)
+	}
+
+	for _, f := range document.Folders {
+		folderItem := FolderItem{Key: strconv.Itoa(f.ID), Name: f.Name}
+		if f.ParentID != 0 {
+			folderItem.ParentKey = strconv.Itoa(f.ParentID)
+		}
+
+		batch.Folders = append(batch.Folders, folderItem)
+	}
+
+	for i, n := range document.Notes {
+		noteItem := NoteItem{
+			Source:   fmt.Sprintf("notes[%d]", i),
+			Title:    n.Title,
+			Content:  n.Content,
+			Format:   n.Format,
+			Tags:     n.Tags,
+			IsPublic: n.IsPublic,
+		}
+		if n.FolderID != 0 {
+			noteItem.FolderKey = strconv.Itoa(n.FolderID)
+		}
+
+		batch.Notes = append(batch.Notes, noteItem)
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,296 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iqbaleff214/easynote-backend-go/note"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxNoteLength is the most characters a note's content may hold
+	maxNoteLength = 1_000_000
+	// maxNoteFileSize caps how much of a single file is decompressed: a note
+	// at its longest, every character taking four bytes, plus front matter
+	maxNoteFileSize = 4*maxNoteLength + 64<<10
+	// maxArchiveEntries and maxArchiveSize cap the zip as a whole, so a small
+	// upload can't decompress to more than memory holds
+	maxArchiveEntries = 10_000
+	maxArchiveSize    = 128 << 20
+)
+
+var (
+	errNoteFileTooLarge = errors.New("file is too large to be a note")
+	errNoteTooLong      = fmt.Errorf("note is longer than %d characters", maxNoteLength)
+	errArchiveTooLarge  = fmt.Errorf("zip archive holds more than %d MB or %d files", maxArchiveSize>>20, maxArchiveEntries)
+)
+
+type frontMatter struct {
+	Title    string  `yaml:"title"`
+	Format   string  `yaml:"format"`
+	Tags     tagList `yaml:"tags"`
+	IsPublic bool    `yaml:"is_public"`
+}
+
+// tagList takes tags written either as a YAML list or as one comma
+// separated string.
+type tagList []string
+
+func (t *tagList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		for _, tag := range strings.Split(value.Value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				*t = append(*t, tag)
+			}
+		}
+
+		return nil
+	}
+
+	var tags []string
+	if err := value.Decode(&tags); err != nil {
+		return err
+	}
+
+	*t = tags
+	return nil
+}
+
+// parseMarkdownZip reads a zip of Markdown files, like the one the export
+// writes. Every directory becomes a folder and every .md, .markdown or .txt
+// file a note, with its front matter supplying the title, format and tags.
+// An archive over the entry or size limits fails as a whole.
+func parseMarkdownZip(data []byte) (Batch, error) {
+	var batch Batch
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return batch, errors.New("file isn't a valid zip archive")
+	}
+
+	if len(reader.File) > maxArchiveEntries {
+		return batch, errArchiveTooLarge
+	}
+
+	var declared uint64
+	for _, file := range reader.File {
+		declared += file.UncompressedSize64
+	}
+	if declared > maxArchiveSize {
+		return batch, errArchiveTooLarge
+	}
+
+	// The sizes in the headers can lie, so what's actually read counts too
+	remaining := int64(maxArchiveSize)
+
+	dirs := map[string]bool{}
+
+	var addDir func(dir string)
+	addDir = func(dir string) {
+		if dir == "" || dirs[dir] {
+			return
+		}
+
+		dirs[dir] = true
+		addDir(parentDir(dir))
+	}
+
+	for _, file := range reader.File {
+		name, ok := cleanPath(file.Name)
+		if !ok {
+			continue
+		}
+
+		if file.FileInfo().IsDir() {
+			addDir(name)
+			continue
+		}
+
+		ext := strings.ToLower(path.Ext(name))
+		if ext != ".md" && ext != ".markdown" && ext != ".txt" {
+			batch.Errors = append(batch.Errors, ItemError{Item: name, Error: "not a Markdown or text file"})
+			continue
+		}
+
+		content, err := readZipFile(file, &remaining)
+		if errors.Is(err, errArchiveTooLarge) {
+			return batch, err
+		}
+		if err != nil {
+			batch.Errors = append(batch.Errors, ItemError{Item: name, Error: err.Error()})
+			continue
+		}
+
+		item, err := parseMarkdownNote(name, content)
+		if err != nil {
+			batch.Errors = append(batch.Errors, ItemError{Item: name, Error: err.Error()})
+			continue
+		}
+
+		item.FolderKey = parentDir(name)
+		addDir(item.FolderKey)
+
+		batch.Notes = append(batch.Notes, item)
+	}
+
+	var keys []string
+	for dir := range dirs {
+		keys = append(keys, dir)
+	}
+	sort.Strings(keys)
+
+	for _, dir := range keys {
+		batch.Folders = append(batch.Folders, FolderItem{Key: dir, ParentKey: parentDir(dir), Name: path.Base(dir)})
+	}
+
+	return batch, nil
+}
+
+// cleanPath normalises a path inside the zip, dropping anything that would
+// climb out of it. Hidden files and the metadata macOS adds to zips are
+// skipped.
+func cleanPath(name string) (string, bool) {
+	var parts []string
+
+	for _, part := range strings.Split(strings.ReplaceAll(name, "\\", "/"), "/") {
+		switch {
+		case part == "" || part == "." || part == "..":
+			continue
+		case strings.HasPrefix(part, ".") || part == "__MACOSX":
+			return "", false
+		}
+
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return "", false
+	}
+
+	return strings.Join(parts, "/"), true
+}
+
+func parentDir(name string) string {
+	dir := path.Dir(name)
+	if dir == "." {
+		return ""
+	}
+
+	return dir
+}
+
+// readZipFile reads the file, taking what it read off the archive's
+// remaining budget.
+func readZipFile(file *zip.File, remaining *int64) (string, error) {
+	if file.UncompressedSize64 > maxNoteFileSize {
+		return "", errNoteFileTooLarge
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	// The size in the header can lie, so the read is capped as well
+	limit := min(int64(maxNoteFileSize), *remaining)
+	content, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return "", err
+	}
+
+	*remaining -= int64(len(content))
+	if *remaining < 0 {
+		return "", errArchiveTooLarge
+	}
+
+	if len(content) > maxNoteFileSize {
+		return "", errNoteFileTooLarge
+	}
+
+	return string(content), nil
+}
+
+func parseMarkdownNote(name, content string) (NoteItem, error) {
+	item := NoteItem{Source: name, Format: note.FormatMarkdown}
+	if strings.ToLower(path.Ext(name)) == ".txt" {
+		item.Format = note.FormatPlain
+	}
+
+	matter, body, err := splitFrontMatter(content)
+	if err != nil {
+		return item, err
+	}
+
+	item.Title = matter.Title
+	if item.Title == "" {
+		item.Title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+
+	switch matter.Format {
+	case note.FormatPlain, note.FormatMarkdown, note.FormatHTML:
+		item.Format = matter.Format
+	}
+
+	if utf8.RuneCountInString(body) > maxNoteLength {
+		return item, errNoteTooLong
+	}
+
+	item.Content = body
+	item.Tags = matter.Tags
+	item.IsPublic = matter.IsPublic
+
+	return item, nil
+}
+
+// splitFrontMatter separates a YAML block fenced by --- lines at the top of
+// the file from the note's content.
+func splitFrontMatter(content string) (frontMatter, string, error) {
+	var matter frontMatter
+
+	content = strings.TrimPrefix(content, "\ufeff")
+
+	rest, ok := cutLine(content, "---")
+	if !ok {
+		return matter, content, nil
+	}
+
+	for offset := 0; offset < len(rest); {
+		line := rest[offset:]
+		if end := strings.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+		}
+
+		if strings.TrimRight(line, "\r") == "---" {
+			if err := yaml.Unmarshal([]byte(rest[:offset]), &matter); err != nil {
+				return matter, content, fmt.Errorf("invalid front matter: %w", err)
+			}
+
+			body := strings.TrimLeft(rest[min(offset+len(line)+1, len(rest)):], "\r\n")
+			return matter, body, nil
+		}
+
+		offset += len(line) + 1
+	}
+
+	// An opening fence without a closing one is just content
+	return matter, content, nil
+}
+
+// cutLine returns what follows the first line when that line is marker.
+func cutLine(content, marker string) (string, bool) {
+	line, rest, found := strings.Cut(content, "\n")
+	if !found || strings.TrimRight(line, "\r") != marker {
+		return content, false
+	}
+
+	return rest, true
+}
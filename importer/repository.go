@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type Repository interface {
+	FindByID(userID, id int) (Job, error)
+	Save(job Job) (Job, error)
+	Update(job Job) (Job, error)
+	FailUnfinished(message string) error
+}
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(db *sql.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) FindByID(userID, id int) (Job, error) {
+	var job Job
+	var errs []byte
+	var jobError sql.NullString
+	var finishedAt sql.NullTime
+
+	query := "SELECT id, user_id, format, filename, status, total, imported, failed, errors, error, created_at, updated_at, finished_at " +
+		"FROM import_jobs WHERE user_id = ? AND id = ?"
+
+	err := r.db.QueryRow(query, userID, id).Scan(
+		&job.ID, &job.UserID, &job.Format, &job.Filename, &job.Status, &job.Total, &job.Imported, &job.Failed,
+		&errs, &jobError, &job.CreatedAt, &job.UpdatedAt, &finishedAt,
+	)
+	if err != nil {
+		return job, err
+	}
+	job.Error = jobError.String
+	job.FinishedAt = finishedAt.Time
+
+	if len(errs) > 0 {
+		if err := json.Unmarshal(errs, &job.Errors); err != nil {
+			return job, err
+		}
+	}
+
+	return job, nil
+}
+
+func (r *repository) Save(job Job) (Job, error) {
+	query := "INSERT INTO import_jobs SET user_id = ?, format = ?, filename = ?, status = ?, created_at = NOW(), updated_at = NOW()"
+
+	res, err := r.db.Exec(query, job.UserID, job.Format, job.Filename, job.Status)
+	if err != nil {
+		return job, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return job, err
+	}
+
+	job.ID = int(id)
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = time.Now()
+
+	return job, nil
+}
+
+// Update saves the job's progress. Once the job has finished, its finish
+// time is recorded as well.
+func (r *repository) Update(job Job) (Job, error) {
+	var errs any
+	if len(job.Errors) > 0 {
+		encoded, err := json.Marshal(job.Errors)
+		if err != nil {
+			return job, err
+		}
+
+		errs = string(encoded)
+	}
+
+	var jobError any
+	if job.Error != "" {
+		jobError = job.Error
+	}
+
+	finished := job.Status == StatusCompleted || job.Status == StatusFailed
+
+	query := "UPDATE import_jobs SET status = ?, total = ?, imported = ?, failed = ?, errors = ?, error = ?, updated_at = NOW(), " +
+		"finished_at = IF(?, NOW(), NULL) WHERE id = ?"
+
+	_, err := r.db.Exec(query, job.Status, job.Total, job.Imported, job.Failed, errs, jobError, finished, job.ID)
+	if err != nil {
+		return job, err
+	}
+
+	job.UpdatedAt = time.Now()
+	if finished {
+		job.FinishedAt = time.Now()
+	}
+
+	return job, nil
+}
+
+// FailUnfinished marks every job that's still pending or running as failed,
+// which is what became of them when the server stopped.
+func (r *repository) FailUnfinished(message string) error {
+	query := "UPDATE import_jobs SET status = ?, error = ?, updated_at = NOW(), finished_at = NOW() WHERE status IN (?, ?)"
+
+	_, err := r.db.Exec(query, StatusFailed, message, StatusPending, StatusRunning)
+	return err
+}
@@ -0,0 +1,293 @@
+package importer
+
+import (
+	"errors"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iqbaleff214/easynote-backend-go/folder"
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+)
+
+const (
+	// maxConcurrentJobs caps how many imports run at once across all users
+	maxConcurrentJobs = 2
+	// maxReportedErrors caps the error report, the failed count keeps going
+	maxReportedErrors = 500
+	// progressInterval is how many notes are imported between progress saves
+	progressInterval = 25
+)
+
+type Service interface {
+	StartImport(input ImportInput, filename string, data []byte, userID int) (Job, error)
+	FindJob(userID, jobID int) (Job, error)
+	FailInterruptedJobs() error
+}
+
+type service struct {
+	repository    Repository
+	noteService   note.Service
+	folderService folder.Service
+	slots         chan struct{}
+}
+
+func NewService(repository Repository, noteService note.Service, folderService folder.Service) *service {
+	return &service{repository, noteService, folderService, make(chan struct{}, maxConcurrentJobs)}
+}
+
+// StartImport queues the upload as a job and returns right away, the notes
+// get created in the background. Without a format, it's told by the file's
+// extension.
+func (s *service) StartImport(input ImportInput, filename string, data []byte, userID int) (Job, error) {
+	var job Job
+
+	if userID == 0 {
+		return job, helper.ErrNoSession
+	}
+
+	if len(data) == 0 {
+		return job, ErrEmptyFile
+	}
+
+	job.UserID = userID
+	job.Filename = truncate(path.Base(strings.ReplaceAll(filename, "\\", "/")), 255)
+	job.Status = StatusPending
+
+	job.Format = input.Format
+	if job.Format == "" {
+		job.Format = formatOf(filename)
+	}
+
+	if job.Format == "" {
+		return job, ErrUnsupportedFormat
+	}
+
+	job, err := s.repository.Save(job)
+	if err != nil {
+		return job, err
+	}
+
+	go s.run(job, data)
+
+	return job, nil
+}
+
+func formatOf(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".zip":
+		return FormatMarkdown
+	case ".enex":
+		return FormatENEX
+	case ".json":
+		return FormatJSON
+	}
+
+	return ""
+}
+
+func (s *service) FindJob(userID, jobID int) (Job, error) {
+	job, err := s.repository.FindByID(userID, jobID)
+	if err != nil {
+		return job, helper.NoRows(err, ErrNotFound)
+	}
+
+	return job, nil
+}
+
+// FailInterruptedJobs fails the jobs a restart cut short. Their uploads only
+// lived in memory, so they can't be picked up again.
+func (s *service) FailInterruptedJobs() error {
+	return s.repository.FailUnfinished("import was interrupted, please upload the file again")
+}
+
+func (s *service) run(job Job, data []byte) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("import %d: %v", job.ID, recovered)
+			job.Status = StatusFailed
+			job.Error = "import stopped unexpectedly"
+			s.save(job)
+		}
+	}()
+
+	job.Status = StatusRunning
+	job = s.save(job)
+
+	batch, err := parse(job.Format, data)
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+		s.save(job)
+		return
+	}
+
+	// Folders, notes and the files that couldn't be read all count as items
+	job.Total = len(batch.Folders) + len(batch.Notes) + len(batch.Errors)
+	for _, itemError := range batch.Errors {
+		job.fail(itemError.Item, itemError.Error)
+	}
+
+	folderIDs := s.importFolders(&job, batch.Folders)
+
+	for i, item := range batch.Notes {
+		if err := s.importNote(item, folderIDs[item.FolderKey], job.UserID); err != nil {
+			job.fail(item.Source, err.Error())
+		} else {
+			job.Imported++
+		}
+
+		if (i+1)%progressInterval == 0 {
+			job = s.save(job)
+		}
+	}
+
+	job.Status = StatusCompleted
+	s.save(job)
+}
+
+func parse(format string, data []byte) (Batch, error) {
+	switch format {
+	case FormatMarkdown:
+		return parseMarkdownZip(data)
+	case FormatENEX:
+		return parseENEX(data)
+	case FormatJSON:
+		return parseJSON(data)
+	}
+
+	return Batch{}, ErrUnsupportedFormat
+}
+
+// save records the job's progress. A failure here shouldn't stop the import,
+// so it only gets logged.
+func (s *service) save(job Job) Job {
+	updated, err := s.repository.Update(job)
+	if err != nil {
+		log.Printf("import %d: %v", job.ID, err)
+		return job
+	}
+
+	return updated
+}
+
+// fail counts an item as failed, reporting why unless the report is full.
+func (j *Job) fail(item, reason string) {
+	j.Failed++
+
+	if len(j.Errors) < maxReportedErrors {
+		j.Errors = append(j.Errors, ItemError{Item: item, Error: reason})
+	}
+}
+
+// importFolders creates the folders parents first and returns the new id of
+// each key. A folder that fails, or whose parent failed, is left out, and
+// whatever was meant to go in it ends up a level higher.
+func (s *service) importFolders(job *Job, items []FolderItem) map[string]int {
+	folderIDs := map[string]int{}
+
+	byKey := map[string]FolderItem{}
+	for _, item := range items {
+		byKey[item.Key] = item
+	}
+
+	visiting := map[string]bool{}
+
+	var create func(key string) int
+	create = func(key string) int {
+		if id, ok := folderIDs[key]; ok {
+			return id
+		}
+
+		item, ok := byKey[key]
+		if !ok || visiting[key] {
+			return 0
+		}
+		visiting[key] = true
+
+		input := folder.CreateFolderInput{Name: truncate(strings.TrimSpace(item.Name), 255)}
+		if item.ParentKey != "" {
+			input.ParentID = create(item.ParentKey)
+		}
+
+		folderIDs[key] = 0
+
+		if errs := helper.Validate(input); errs != nil {
+			job.fail("folder "+item.Name, describe(errs))
+			return 0
+		}
+
+		newFolder, err := s.folderService.CreateFolder(input, job.UserID)
+		if err != nil {
+			job.fail("folder "+item.Name, err.Error())
+			return 0
+		}
+
+		job.Imported++
+		folderIDs[key] = newFolder.ID
+		return newFolder.ID
+	}
+
+	for _, item := range items {
+		create(item.Key)
+	}
+
+	return folderIDs
+}
+
+func (s *service) importNote(item NoteItem, folderID, userID int) error {
+	input := note.CreateNoteInput{
+		Title:    truncate(strings.TrimSpace(item.Title), 255),
+		Content:  item.Content,
+		Format:   item.Format,
+		IsPublic: item.IsPublic,
+		FolderID: folderID,
+		Tags:     item.Tags,
+	}
+
+	if input.Title == "" {
+		input.Title = "Untitled"
+	}
+
+	if input.Format == "" {
+		input.Format = note.FormatPlain
+	}
+
+	if errs := helper.Validate(input); errs != nil {
+		return errors.New(describe(errs))
+	}
+
+	_, err := s.noteService.CreateNote(input, userID)
+	return err
+}
+
+// describe turns validation errors into one line for the error report.
+func describe(errs helper.ValidationErrors) string {
+	var fields []string
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var messages []string
+	for _, field := range fields {
+		messages = append(messages, field+" "+errs[field])
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// truncate cuts s down to at most max characters.
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// logMailer doesn't deliver anything. It writes each message to an .eml file
+// in dir, or to the log when dir is empty, which is handy for local testing.
+type logMailer struct {
+	dir  string
+	from string
+}
+
+func NewLogMailer(dir, from string) *logMailer {
+	return &logMailer{dir, from}
+}
+
+func (m *logMailer) Send(message Message) error {
+	email := format(m.from, message)
+
+	if m.dir == "" {
+		log.Printf("mail:\n%s\n", email)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(message.To, "_"))
+
+	return os.WriteFile(filepath.Join(m.dir, name), email, 0o644)
+}
@@ -2,14 +2,20 @@ package main
 
 import (
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/iqbaleff214/easynote-backend-go/attachment"
 	"github.com/iqbaleff214/easynote-backend-go/auth"
+	"github.com/iqbaleff214/easynote-backend-go/export"
 	"github.com/iqbaleff214/easynote-backend-go/folder"
 	"github.com/iqbaleff214/easynote-backend-go/handler"
+	"github.com/iqbaleff214/easynote-backend-go/importer"
 	"github.com/iqbaleff214/easynote-backend-go/note"
+	"github.com/iqbaleff214/easynote-backend-go/session"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
 	"github.com/iqbaleff214/easynote-backend-go/user"
 )
 
@@ -24,24 +30,79 @@ func main() {
 	defer func() { db.Close() }()
 
 	// repository init
+	transactionManager := transaction.NewManager(db)
 	userRepository := user.NewRepository(db)
 	folderRepository := folder.NewRepository(db)
 	noteRepository := note.NewRepository(db)
+	sessionRepository := session.NewRepository(db)
+	importRepository := importer.NewRepository(db)
+	attachmentRepository := attachment.NewRepository(db)
 
 	// service init
-	authService := auth.NewService(appConfig.jwtSecret)
-	userService := user.NewService(userRepository)
-	folderService := folder.NewService(folderRepository)
-	noteService := note.NewService(noteRepository)
+	keys, err := signingKeys()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := blobStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	authService, err := auth.NewService(keys, appConfig.jwtIssuer, appConfig.jwtAudience, appConfig.tokenTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	userService := user.NewService(userRepository, transactionManager, mailer(), appConfig.appURL, appConfig.loginPolicy)
+	sessionService := session.NewService(sessionRepository, appConfig.refreshTTL)
+	folderService := folder.NewService(folderRepository, transactionManager)
+	noteService := note.NewService(noteRepository, transactionManager)
+	exportService := export.NewService(noteRepository, folderRepository)
+	importService := importer.NewService(importRepository, noteService, folderService)
+	attachmentService := attachment.NewService(attachmentRepository, transactionManager, noteService, store, appConfig.attachments)
 
 	// handler init
-	userHandler := handler.NewUserHandler(userService, authService)
+	userHandler := handler.NewUserHandler(userService, authService, sessionService)
+	sessionHandler := handler.NewSessionHandler(sessionService, authService)
+	jwksHandler := handler.NewJWKSHandler(authService)
 	folderHandler := handler.NewFolderHandler(folderService)
-	noteHandler := handler.NewNoteHandler(noteService)
-
-	app := fiber.New()
+	noteHandler := handler.NewNoteHandler(noteService, attachmentService)
+	trashHandler := handler.NewTrashHandler(noteService, folderService)
+	tagHandler := handler.NewTagHandler(noteService)
+	shareHandler := handler.NewShareHandler(noteService, userService)
+	exportHandler := handler.NewExportHandler(exportService)
+	importHandler := handler.NewImportHandler(importService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+
+	// background jobs
+	if err := importService.FailInterruptedJobs(); err != nil {
+		log.Println("import:", err)
+	}
+	go sweepTrash(noteService, folderService, appConfig.trashRetention, time.Hour)
+	go sweepSessions(sessionService, time.Hour)
+	go sweepLoginAttempts(userService, time.Hour)
+	go sweepAttachments(attachmentService, time.Hour)
+	go func() {
+		if err := attachmentService.ProcessPendingThumbnails(); err != nil {
+			log.Println("thumbnails:", err)
+		}
+	}()
+
+	limits := appConfig.rateLimits
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handler.ErrorHandler,
+		ProxyHeader:  appConfig.proxyHeader,
+		BodyLimit:    bodyLimit(),
+
+		// Only the configured proxies get to set the client IP through the header
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          appConfig.trustedProxies,
+	})
 	app.Use(cors.New())
 
+	app.Get("/.well-known/jwks.json", jwksHandler.FindKeys)
+
 	api := app.Group("/api/v1", logger.New())
 
 	// Public
@@ -52,29 +113,87 @@ func main() {
 			"Date":         "30/01/2024",
 		})
 	})
-	api.Get("/search", noteHandler.FindPublicNotes)
+	api.Get("/search", handler.RateLimiter(limits.search, nil), noteHandler.FindPublicNotes)
+	api.Get("/s/:token", handler.RateLimiter(limits.shareLink, nil), shareHandler.FindLinkedNote)
 
 	// User Domain
-	api.Post("/register", userHandler.RegisterUser)
-	api.Post("/login", userHandler.Login)
+	loginLimiter := handler.RateLimiter(limits.login, nil)
+	passwordLimiter := handler.RateLimiter(limits.password, nil)
 
-	api.Use(handler.AuthMiddleware(appConfig.jwtSecret, userService))
+	api.Post("/register", handler.RateLimiter(limits.register, nil), userHandler.RegisterUser)
+	api.Post("/login", loginLimiter, handler.RateLimiter(limits.loginAccount, handler.LoginEmailKey), userHandler.Login)
+	api.Post("/login/2fa", loginLimiter, handler.RateLimiter(limits.twoFactor, handler.ChallengeKey), userHandler.LoginWithTwoFactor)
+	api.Post("/token/refresh", loginLimiter, sessionHandler.RefreshToken)
+	api.Post("/password/forgot", passwordLimiter, userHandler.ForgotPassword)
+	api.Post("/password/reset", passwordLimiter, userHandler.ResetPassword)
+	api.Post("/email/verify", passwordLimiter, userHandler.VerifyEmail)
+
+	api.Use(handler.AuthMiddleware(authService, userService, sessionService))
 
 	api.Get("/profile", userHandler.CurrentUser)
 	api.Put("/profile", userHandler.UpdateUser)
+	api.Post("/email/resend", userHandler.ResendVerification)
+	api.Post("/profile/2fa", userHandler.EnableTwoFactor)
+	api.Post("/profile/2fa/confirm", userHandler.ConfirmTwoFactor)
+	api.Delete("/profile/2fa", userHandler.DisableTwoFactor)
+	api.Post("/logout", sessionHandler.Logout)
+	api.Get("/profile/storage", attachmentHandler.FindUsage)
+	api.Get("/sessions", sessionHandler.FindSessions)
+	api.Delete("/sessions", sessionHandler.RevokeOtherSessions)
+	api.Delete("/sessions/:id", sessionHandler.RevokeSession)
 
 	// Note Domain
 	api.Get("/notes", noteHandler.FindNotes)
 	api.Post("/notes", noteHandler.CreateNote)
 	api.Get("/notes/:id", noteHandler.FindNote)
 	api.Put("/notes/:id", noteHandler.UpdateNote)
+	api.Patch("/notes/:id/tags", noteHandler.UpdateNoteTags)
 	api.Delete("/notes/:id", noteHandler.DeleteNote)
+	api.Get("/notes/:id/revisions", noteHandler.FindRevisions)
+	api.Get("/notes/:id/revisions/:rev", noteHandler.FindRevision)
+	api.Post("/notes/:id/revisions/:rev/restore", noteHandler.RestoreRevision)
+	api.Get("/notes/:id/shares", shareHandler.FindNoteShares)
+	api.Post("/notes/:id/shares", shareHandler.ShareNote)
+	api.Delete("/notes/:id/shares/:user_id", shareHandler.UnshareNote)
+	api.Get("/notes/:id/links", shareHandler.FindShareLinks)
+	api.Post("/notes/:id/links", shareHandler.CreateShareLink)
+	api.Delete("/notes/:id/links/:link_id", shareHandler.RevokeShareLink)
+	api.Get("/notes/:id/attachments", attachmentHandler.FindAttachments)
+	api.Post("/notes/:id/attachments", attachmentHandler.UploadAttachment)
+	api.Get("/notes/:id/attachments/:attachment_id", attachmentHandler.DownloadAttachment)
+	api.Get("/notes/:id/attachments/:attachment_id/thumbnails/:size", attachmentHandler.DownloadThumbnail)
+	api.Delete("/notes/:id/attachments/:attachment_id", attachmentHandler.DeleteAttachment)
+	api.Get("/shared", shareHandler.FindSharedNotes)
+
+	// Tag Domain
+	api.Get("/tags", tagHandler.FindTags)
+	api.Put("/tags/:name", tagHandler.RenameTag)
+	api.Post("/tags/:name/merge", tagHandler.MergeTag)
+	api.Delete("/tags/:name", tagHandler.DeleteTag)
+	api.Get("/tags/:name/notes", tagHandler.FindTagNotes)
 
 	// Folder Domain
 	api.Get("/folders", folderHandler.FindFolders)
+	api.Get("/folders/tree", folderHandler.FindFolderTree)
+	api.Get("/folders/:id", folderHandler.FindFolder)
 	api.Post("/folders", folderHandler.CreateFolder)
 	api.Put("/folders/:id", folderHandler.UpdateFolder)
 	api.Delete("/folders/:id", folderHandler.DeleteFolder)
+	api.Get("/folders/:id/shares", shareHandler.FindFolderShares)
+	api.Post("/folders/:id/shares", shareHandler.ShareFolder)
+	api.Delete("/folders/:id/shares/:user_id", shareHandler.UnshareFolder)
+
+	// Trash
+	api.Get("/trash", trashHandler.FindTrash)
+	api.Post("/trash/:type/:id/restore", trashHandler.Restore)
+	api.Delete("/trash/:type/:id", trashHandler.Purge)
+
+	// Export
+	api.Get("/export", exportHandler.ExportNotes)
+
+	// Import
+	api.Post("/import", handler.RateLimiter(limits.importing, nil), importHandler.StartImport)
+	api.Get("/import/:id", importHandler.FindImport)
 
 	log.Fatal(app.Listen(":8000"))
 }
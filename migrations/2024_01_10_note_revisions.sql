@@ -0,0 +1,16 @@
+-- Note revision history.
+--
+-- An update that changes the title or content keeps the previous version as
+-- a revision.
+
+CREATE TABLE `note_revisions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `note_id` bigint unsigned NOT NULL,
+  `title` varchar(255) NOT NULL,
+  `content` longtext,
+  `created_at` timestamp NULL DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `note_id` (`note_id`),
+  CONSTRAINT `note_revisions_ibfk_1` FOREIGN KEY (`note_id`) REFERENCES `notes` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,8 @@
+-- Soft delete notes and folders into the trash.
+--
+-- Rows with a deleted_at are in the trash until they're restored or the
+-- sweeper purges them once the retention period is over.
+
+ALTER TABLE `notes` ADD COLUMN `deleted_at` timestamp NULL DEFAULT NULL AFTER `updated_at`;
+
+ALTER TABLE `folders` ADD COLUMN `deleted_at` timestamp NULL DEFAULT NULL AFTER `updated_at`;
@@ -0,0 +1,8 @@
+-- Full-text search over notes.
+--
+-- The title-only index lets title matches rank above content matches. InnoDB
+-- only builds one FULLTEXT index per statement.
+
+ALTER TABLE `notes` ADD FULLTEXT KEY `notes_title_fulltext` (`title`);
+
+ALTER TABLE `notes` ADD FULLTEXT KEY `notes_title_content_fulltext` (`title`,`content`);
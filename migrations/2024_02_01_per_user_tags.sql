@@ -0,0 +1,38 @@
+-- Scope tags per user.
+--
+-- Every shared tag is copied once for each user whose notes use it, with its
+-- name lowercased and its whitespace collapsed, the note links are moved over
+-- to the copies and the old shared tags are dropped. Names that only differed
+-- by case or whitespace end up as a single tag per user.
+
+ALTER TABLE `tags` ADD COLUMN `user_id` bigint unsigned DEFAULT NULL AFTER `name`;
+
+INSERT INTO `tags` (`name`, `user_id`, `created_at`, `updated_at`)
+SELECT LOWER(TRIM(REGEXP_REPLACE(t.`name`, '[[:space:]]+', ' '))), n.`user_id`, MIN(t.`created_at`), NOW()
+FROM `tags` t
+JOIN `note_tags` nt ON nt.`tag_id` = t.`id`
+JOIN `notes` n ON n.`id` = nt.`note_id`
+WHERE t.`user_id` IS NULL
+GROUP BY LOWER(TRIM(REGEXP_REPLACE(t.`name`, '[[:space:]]+', ' '))), n.`user_id`;
+
+UPDATE `note_tags` nt
+JOIN `notes` n ON n.`id` = nt.`note_id`
+JOIN `tags` shared ON shared.`id` = nt.`tag_id` AND shared.`user_id` IS NULL
+JOIN `tags` owned ON owned.`user_id` = n.`user_id`
+  AND owned.`name` = LOWER(TRIM(REGEXP_REPLACE(shared.`name`, '[[:space:]]+', ' ')))
+SET nt.`tag_id` = owned.`id`;
+
+-- Notes tagged with both "Work" and "work" now link the same tag twice
+DELETE nt FROM `note_tags` nt
+JOIN `note_tags` duplicate ON duplicate.`note_id` = nt.`note_id`
+  AND duplicate.`tag_id` = nt.`tag_id` AND duplicate.`id` < nt.`id`;
+
+DELETE FROM `tags` WHERE `user_id` IS NULL;
+
+ALTER TABLE `tags`
+  MODIFY `user_id` bigint unsigned NOT NULL,
+  ADD UNIQUE KEY `tags_user_id_name_unique` (`user_id`,`name`),
+  ADD CONSTRAINT `tags_user_id_foreign` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
+
+ALTER TABLE `note_tags`
+  ADD UNIQUE KEY `note_tags_note_id_tag_id_unique` (`note_id`,`tag_id`);
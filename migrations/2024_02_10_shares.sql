@@ -0,0 +1,25 @@
+-- Share notes and folders with other users.
+--
+-- A share points at either a note or a folder, and a user gets at most one
+-- share of each.
+
+CREATE TABLE `shares` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `owner_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `note_id` bigint unsigned DEFAULT NULL,
+  `folder_id` bigint unsigned DEFAULT NULL,
+  `permission` enum('viewer','commenter','editor') NOT NULL DEFAULT 'viewer',
+  `created_at` timestamp NULL DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `shares_user_id_note_id_unique` (`user_id`,`note_id`),
+  UNIQUE KEY `shares_user_id_folder_id_unique` (`user_id`,`folder_id`),
+  KEY `owner_id` (`owner_id`),
+  KEY `note_id` (`note_id`),
+  KEY `folder_id` (`folder_id`),
+  CONSTRAINT `shares_ibfk_1` FOREIGN KEY (`owner_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `shares_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `shares_ibfk_3` FOREIGN KEY (`note_id`) REFERENCES `notes` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `shares_ibfk_4` FOREIGN KEY (`folder_id`) REFERENCES `folders` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,21 @@
+-- Password resets and email verification.
+--
+-- Existing users start out unverified. Tokens are stored hashed, and a
+-- verification token remembers the email it was sent to, so changing the
+-- email voids it.
+
+ALTER TABLE `users` ADD COLUMN `email_verified_at` timestamp NULL DEFAULT NULL AFTER `password`;
+
+CREATE TABLE `user_tokens` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `type` enum('password_reset','email_verification') NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `email` varchar(255) NOT NULL,
+  `expires_at` timestamp NOT NULL,
+  `created_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `user_tokens_token_hash_unique` (`token_hash`),
+  KEY `user_tokens_user_id_type_index` (`user_id`,`type`),
+  CONSTRAINT `user_tokens_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
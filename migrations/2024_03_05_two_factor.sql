@@ -0,0 +1,22 @@
+-- TOTP two-factor login with recovery codes.
+--
+-- two_factor_last_step is the last accepted TOTP step, so a code can't be
+-- used twice. Login challenges are user tokens of their own type.
+
+ALTER TABLE `users`
+  ADD COLUMN `two_factor_secret` varchar(255) DEFAULT NULL AFTER `email_verified_at`,
+  ADD COLUMN `two_factor_confirmed_at` timestamp NULL DEFAULT NULL AFTER `two_factor_secret`,
+  ADD COLUMN `two_factor_last_step` bigint NOT NULL DEFAULT '0' AFTER `two_factor_confirmed_at`;
+
+ALTER TABLE `user_tokens`
+  MODIFY `type` enum('password_reset','email_verification','two_factor_login') NOT NULL;
+
+CREATE TABLE `two_factor_recovery_codes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `created_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `two_factor_recovery_codes_user_id_code_hash_unique` (`user_id`,`code_hash`),
+  CONSTRAINT `two_factor_recovery_codes_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,13 @@
+-- Failed logins per email for the progressive lockout.
+--
+-- Keyed by the normalized email whether or not it belongs to a user, so a
+-- lockout doesn't reveal which accounts exist.
+
+CREATE TABLE `login_attempts` (
+  `email` varchar(255) NOT NULL,
+  `failures` int unsigned NOT NULL DEFAULT '0',
+  `locked_until` timestamp NULL DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
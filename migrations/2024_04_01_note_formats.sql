@@ -0,0 +1,8 @@
+-- Notes remember whether their content is plain text, Markdown or HTML, and
+-- so do their revisions, so restoring one brings its format back too.
+--
+-- Existing notes and revisions were all plain text.
+
+ALTER TABLE `notes` ADD COLUMN `format` enum('plain','markdown','html') NOT NULL DEFAULT 'plain' AFTER `content`;
+
+ALTER TABLE `note_revisions` ADD COLUMN `format` enum('plain','markdown','html') NOT NULL DEFAULT 'plain' AFTER `content`;
@@ -0,0 +1,22 @@
+-- Note attachments kept in blob storage.
+--
+-- note_id is NULL for attachments whose note was deleted. The sweeper
+-- removes those along with their blobs.
+
+CREATE TABLE `attachments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `note_id` bigint unsigned DEFAULT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `filename` varchar(255) NOT NULL,
+  `content_type` varchar(255) NOT NULL,
+  `size` bigint unsigned NOT NULL,
+  `storage_key` varchar(255) NOT NULL,
+  `created_at` timestamp NULL DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `attachments_storage_key_unique` (`storage_key`),
+  KEY `attachments_note_id_foreign` (`note_id`),
+  KEY `attachments_user_id_foreign` (`user_id`),
+  CONSTRAINT `attachments_note_id_foreign` FOREIGN KEY (`note_id`) REFERENCES `notes` (`id`) ON DELETE SET NULL,
+  CONSTRAINT `attachments_user_id_foreign` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,25 @@
+-- Image dimensions and background thumbnails for attachments.
+--
+-- thumbnail_status stays NULL for attachments that aren't images, including
+-- everything uploaded before this.
+
+ALTER TABLE `attachments`
+  ADD COLUMN `width` int unsigned DEFAULT NULL AFTER `storage_key`,
+  ADD COLUMN `height` int unsigned DEFAULT NULL AFTER `width`,
+  ADD COLUMN `thumbnail_status` enum('pending','ready','failed') DEFAULT NULL AFTER `height`,
+  ADD KEY `attachments_thumbnail_status_index` (`thumbnail_status`);
+
+CREATE TABLE `attachment_thumbnails` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `attachment_id` bigint unsigned NOT NULL,
+  `size` enum('small','medium','large') NOT NULL,
+  `width` int unsigned NOT NULL,
+  `height` int unsigned NOT NULL,
+  `content_type` varchar(255) NOT NULL,
+  `storage_key` varchar(255) NOT NULL,
+  `created_at` timestamp NULL DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `attachment_thumbnails_attachment_id_size_unique` (`attachment_id`,`size`),
+  CONSTRAINT `attachment_thumbnails_attachment_id_foreign` FOREIGN KEY (`attachment_id`) REFERENCES `attachments` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,100 @@
+package note
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	eq := func(text string) DiffLine { return DiffLine{Op: DiffEqual, Text: text} }
+	ins := func(text string) DiffLine { return DiffLine{Op: DiffInsert, Text: text} }
+	del := func(text string) DiffLine { return DiffLine{Op: DiffDelete, Text: text} }
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []DiffLine
+	}{
+		{"both empty", "", "", []DiffLine{eq("")}},
+		{"from empty", "", "a", []DiffLine{del(""), ins("a")}},
+		{"to empty", "a", "", []DiffLine{del("a"), ins("")}},
+		{"unchanged", "a\nb", "a\nb", []DiffLine{eq("a"), eq("b")}},
+		{"line added at the end", "a\nb", "a\nb\nc", []DiffLine{eq("a"), eq("b"), ins("c")}},
+		{"line removed at the start", "a\nb\nc", "b\nc", []DiffLine{del("a"), eq("b"), eq("c")}},
+		{"line changed in the middle", "a\nb\nc", "a\nx\nc", []DiffLine{eq("a"), del("b"), ins("x"), eq("c")}},
+		{"common lines inside the change", "a\nb\nc\nd", "x\nb\nc\ny", []DiffLine{del("a"), ins("x"), eq("b"), eq("c"), del("d"), ins("y")}},
+		{"repeated lines", "a\na", "a", []DiffLine{eq("a"), del("a")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffLines(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+
+			assertDiffApplies(t, got, tt.from, tt.to)
+		})
+	}
+}
+
+func TestDiffLinesPastCellCap(t *testing.T) {
+	var from, to []string
+	for i := 0; i < 2100; i++ {
+		from = append(from, "from "+strconv.Itoa(i))
+		to = append(to, "to "+strconv.Itoa(i))
+	}
+
+	// Shared first and last lines are trimmed before the cap applies
+	fromText := "same\n" + strings.Join(from, "\n") + "\nsame"
+	toText := "same\n" + strings.Join(to, "\n") + "\nsame"
+
+	got := DiffLines(fromText, toText)
+
+	if len(got) != 2+len(from)+len(to) {
+		t.Fatalf("got %d lines, want %d", len(got), 2+len(from)+len(to))
+	}
+
+	if got[0] != (DiffLine{Op: DiffEqual, Text: "same"}) || got[len(got)-1] != (DiffLine{Op: DiffEqual, Text: "same"}) {
+		t.Errorf("common first and last lines aren't kept as equal: %v, %v", got[0], got[len(got)-1])
+	}
+
+	for i, line := range got[1 : len(got)-1] {
+		want := DiffDelete
+		if i >= len(from) {
+			want = DiffInsert
+		}
+
+		if line.Op != want {
+			t.Fatalf("line %d is %s, want %s", i+1, line.Op, want)
+		}
+	}
+
+	assertDiffApplies(t, got, fromText, toText)
+}
+
+// assertDiffApplies checks the diff leads from one text to the other.
+func assertDiffApplies(t *testing.T, diff []DiffLine, from, to string) {
+	t.Helper()
+
+	var before, after []string
+	for _, line := range diff {
+		if line.Op != DiffInsert {
+			before = append(before, line.Text)
+		}
+		if line.Op != DiffDelete {
+			after = append(after, line.Text)
+		}
+	}
+
+	if got := strings.Join(before, "\n"); got != from {
+		t.Errorf("diff starts from %q, want %q", got, from)
+	}
+
+	if got := strings.Join(after, "\n"); got != to {
+		t.Errorf("diff leads to %q, want %q", got, to)
+	}
+}
@@ -6,6 +6,8 @@ type Note struct {
 	ID         int
 	Title      string
 	Content    string
+	Format     string
+	HTML       string
 	IsPublic   bool
 	UserID     int
 	UserName   string
@@ -14,12 +16,53 @@ type Note struct {
 	Tags       []Tag
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+	DeletedAt  time.Time
+	Score      float64
+	Snippet    string
+	Permission string
 }
 
 type Tag struct {
 	ID        int
 	Name      string
+	UserID    int
 	NoteID    int
+	NoteCount int
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+type Revision struct {
+	ID        int
+	NoteID    int
+	Title     string
+	Content   string
+	Format    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Share struct {
+	ID         int
+	OwnerID    int
+	UserID     int
+	UserName   string
+	UserEmail  string
+	NoteID     int
+	FolderID   int
+	Permission string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type ShareLink struct {
+	ID           int
+	NoteID       int
+	UserID       int
+	Token        string
+	TokenHash    string
+	PasswordHash string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
@@ -0,0 +1,18 @@
+package note
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var (
+	ErrNotFound          = helper.NewError(helper.ErrNotFound, "note_not_found", "note doesn't exist")
+	ErrForbidden         = helper.NewError(helper.ErrForbidden, "note_forbidden", "you don't have permission to do this on the note")
+	ErrRevisionNotFound  = helper.NewError(helper.ErrNotFound, "revision_not_found", "revision doesn't exist")
+	ErrTagNotFound       = helper.NewError(helper.ErrNotFound, "tag_not_found", "tag doesn't exist")
+	ErrFolderNotFound    = helper.NewError(helper.ErrNotFound, "folder_not_found", "folder doesn't exist")
+	ErrFolderForbidden   = helper.NewError(helper.ErrForbidden, "folder_forbidden", "folder isn't one of your folders")
+	ErrInvalidPermission = helper.NewError(helper.ErrValidation, "invalid_permission", "permission must be viewer, commenter or editor")
+	ErrShareWithOwner    = helper.NewError(helper.ErrValidation, "share_with_owner", "cannot share with the owner")
+	ErrEmptyTagName      = helper.NewError(helper.ErrValidation, "empty_tag_name", "tag name cannot be empty")
+	ErrMergeTagIntoSelf  = helper.NewError(helper.ErrValidation, "merge_tag_into_self", "cannot merge a tag into itself")
+	ErrExpiryInPast      = helper.NewError(helper.ErrValidation, "expiry_in_past", "expiry must be in the future")
+	ErrInvalidRender     = helper.NewError(helper.ErrValidation, "invalid_render", "render must be html")
+)
@@ -3,30 +3,98 @@ package note
 import "time"
 
 type NoteFormatter struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	IsPublic  bool      `json:"is_public"`
-	Folder    string    `json:"folder,omitempty"`
-	FolderID  int       `json:"folder_id,omitempty"`
-	Tags      []string  `json:"tags"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	Format     string    `json:"format"`
+	HTML       string    `json:"html,omitempty"`
+	IsPublic   bool      `json:"is_public"`
+	Folder     string    `json:"folder,omitempty"`
+	FolderID   int       `json:"folder_id,omitempty"`
+	Tags       []string  `json:"tags"`
+	Snippet    string    `json:"snippet,omitempty"`
+	Permission string    `json:"permission,omitempty"`
+	Owner      string    `json:"owner,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type NotePublicFormatter struct {
 	ID        int       `json:"id"`
 	Title     string    `json:"title"`
 	Content   string    `json:"content"`
+	Format    string    `json:"format"`
+	HTML      string    `json:"html,omitempty"`
 	Author    string    `json:"author"`
 	Tags      []string  `json:"tags"`
+	Snippet   string    `json:"snippet,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type TrashedNoteFormatter struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Folder    string    `json:"folder,omitempty"`
+	FolderID  int       `json:"folder_id,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type ShareFormatter struct {
+	UserID     int       `json:"user_id"`
+	Name       string    `json:"name"`
+	Email      string    `json:"email"`
+	NoteID     int       `json:"note_id,omitempty"`
+	FolderID   int       `json:"folder_id,omitempty"`
+	Permission string    `json:"permission"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ShareRequestFormatter is what sharing answers with. It leaves out who the
+// email belongs to, so it reads the same whether or not anyone does.
+type ShareRequestFormatter struct {
+	Email      string `json:"email"`
+	NoteID     int    `json:"note_id,omitempty"`
+	FolderID   int    `json:"folder_id,omitempty"`
+	Permission string `json:"permission"`
+}
+
+type ShareLinkFormatter struct {
+	ID          int        `json:"id"`
+	NoteID      int        `json:"note_id"`
+	Token       string     `json:"token,omitempty"`
+	HasPassword bool       `json:"has_password"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
 type TagFormatter struct {
-	ID   int    `json:"id"`
-	Name string `json:"tag"`
+	ID        int    `json:"id"`
+	Name      string `json:"tag"`
+	NoteCount int    `json:"note_count"`
+}
+
+type RevisionFormatter struct {
+	ID        int       `json:"id"`
+	NoteID    int       `json:"note_id"`
+	Title     string    `json:"title"`
+	Format    string    `json:"format"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RevisionDetailFormatter struct {
+	ID        int                 `json:"id"`
+	NoteID    int                 `json:"note_id"`
+	Title     string              `json:"title"`
+	Content   string              `json:"content"`
+	Format    string              `json:"format"`
+	Diff      []DiffLineFormatter `json:"diff"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type DiffLineFormatter struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
 }
 
 func FormatNote(note Note) NoteFormatter {
@@ -36,17 +104,28 @@ func FormatNote(note Note) NoteFormatter {
 		tags = append(tags, tag.Name)
 	}
 
-	return NoteFormatter{
+	noteFormatter := NoteFormatter{
 		ID:        note.ID,
 		Title:     note.Title,
 		Content:   note.Content,
+		Format:    note.Format,
+		HTML:      note.HTML,
 		IsPublic:  note.IsPublic,
 		Folder:    note.FolderName,
 		FolderID:  note.FolderID,
 		Tags:      tags,
+		Snippet:   note.Snippet,
 		CreatedAt: note.CreatedAt,
 		UpdatedAt: note.UpdatedAt,
 	}
+
+	// Shared notes tell the grantee whose note it is and what they can do
+	if note.Permission != "" && note.Permission != PermissionOwner {
+		noteFormatter.Permission = note.Permission
+		noteFormatter.Owner = note.UserName
+	}
+
+	return noteFormatter
 }
 
 func FormatNotes(notes []Note) []NoteFormatter {
@@ -71,8 +150,11 @@ func FormatPublicNote(note Note) NotePublicFormatter {
 		ID:        note.ID,
 		Title:     note.Title,
 		Content:   note.Content,
+		Format:    note.Format,
+		HTML:      note.HTML,
 		Author:    note.UserName,
 		Tags:      tags,
+		Snippet:   note.Snippet,
 		CreatedAt: note.CreatedAt,
 		UpdatedAt: note.UpdatedAt,
 	}
@@ -89,10 +171,59 @@ func FormatPublicNotes(notes []Note) []NotePublicFormatter {
 	return noteFormatters
 }
 
+func FormatTrashedNote(note Note) TrashedNoteFormatter {
+	return TrashedNoteFormatter{
+		ID:        note.ID,
+		Title:     note.Title,
+		Folder:    note.FolderName,
+		FolderID:  note.FolderID,
+		DeletedAt: note.DeletedAt,
+	}
+}
+
+func FormatTrashedNotes(notes []Note) []TrashedNoteFormatter {
+	noteFormatters := []TrashedNoteFormatter{}
+
+	for _, note := range notes {
+		noteFormatter := FormatTrashedNote(note)
+		noteFormatters = append(noteFormatters, noteFormatter)
+	}
+
+	return noteFormatters
+}
+
+func FormatShareLink(link ShareLink) ShareLinkFormatter {
+	shareLinkFormatter := ShareLinkFormatter{
+		ID:          link.ID,
+		NoteID:      link.NoteID,
+		Token:       link.Token,
+		HasPassword: link.HasPassword(),
+		CreatedAt:   link.CreatedAt,
+	}
+
+	if !link.ExpiresAt.IsZero() {
+		shareLinkFormatter.ExpiresAt = &link.ExpiresAt
+	}
+
+	return shareLinkFormatter
+}
+
+func FormatShareLinks(links []ShareLink) []ShareLinkFormatter {
+	shareLinkFormatters := []ShareLinkFormatter{}
+
+	for _, link := range links {
+		shareLinkFormatter := FormatShareLink(link)
+		shareLinkFormatters = append(shareLinkFormatters, shareLinkFormatter)
+	}
+
+	return shareLinkFormatters
+}
+
 func FormatTag(tag Tag) TagFormatter {
 	return TagFormatter{
-		ID:   tag.ID,
-		Name: tag.Name,
+		ID:        tag.ID,
+		Name:      tag.Name,
+		NoteCount: tag.NoteCount,
 	}
 }
 
@@ -106,3 +237,74 @@ func FormatTags(tags []Tag) []TagFormatter {
 
 	return tagFormatters
 }
+
+func FormatRevision(revision Revision) RevisionFormatter {
+	return RevisionFormatter{
+		ID:        revision.ID,
+		NoteID:    revision.NoteID,
+		Title:     revision.Title,
+		Format:    revision.Format,
+		CreatedAt: revision.CreatedAt,
+	}
+}
+
+func FormatRevisions(revisions []Revision) []RevisionFormatter {
+	revisionFormatters := []RevisionFormatter{}
+
+	for _, revision := range revisions {
+		revisionFormatter := FormatRevision(revision)
+		revisionFormatters = append(revisionFormatters, revisionFormatter)
+	}
+
+	return revisionFormatters
+}
+
+func FormatRevisionDetail(revision Revision, diff []DiffLine) RevisionDetailFormatter {
+	diffFormatters := []DiffLineFormatter{}
+
+	for _, line := range diff {
+		diffFormatters = append(diffFormatters, DiffLineFormatter{Op: line.Op, Text: line.Text})
+	}
+
+	return RevisionDetailFormatter{
+		ID:        revision.ID,
+		NoteID:    revision.NoteID,
+		Title:     revision.Title,
+		Content:   revision.Content,
+		Format:    revision.Format,
+		Diff:      diffFormatters,
+		CreatedAt: revision.CreatedAt,
+	}
+}
+
+func FormatShare(share Share) ShareFormatter {
+	return ShareFormatter{
+		UserID:     share.UserID,
+		Name:       share.UserName,
+		Email:      share.UserEmail,
+		NoteID:     share.NoteID,
+		FolderID:   share.FolderID,
+		Permission: share.Permission,
+		UpdatedAt:  share.UpdatedAt,
+	}
+}
+
+func FormatShareRequest(share Share) ShareRequestFormatter {
+	return ShareRequestFormatter{
+		Email:      share.UserEmail,
+		NoteID:     share.NoteID,
+		FolderID:   share.FolderID,
+		Permission: share.Permission,
+	}
+}
+
+func FormatShares(shares []Share) []ShareFormatter {
+	shareFormatters := []ShareFormatter{}
+
+	for _, share := range shares {
+		shareFormatter := FormatShare(share)
+		shareFormatters = append(shareFormatters, shareFormatter)
+	}
+
+	return shareFormatters
+}
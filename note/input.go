@@ -1,16 +1,46 @@
 package note
 
+import "time"
+
 type CreateNoteInput struct {
-	Title    string   `json:"title"`
-	Content  string   `json:"content"`
+	Title    string   `json:"title" validate:"required,max=255"`
+	Content  string   `json:"content" validate:"max=1000000"`
+	Format   string   `json:"format" validate:"oneof=plain markdown html"`
 	IsPublic bool     `json:"is_public"`
-	FolderID int      `json:"folder_id"`
-	Tags     []string `json:"tags"`
+	FolderID int      `json:"folder_id" validate:"min=0"`
+	Tags     []string `json:"tags" validate:"max=20,dive,required,max=50"`
 }
 
+// UpdateNoteInput leaves the folder alone when FolderID is 0, and moves the
+// note out of its folder to the root when it's negative.
 type UpdateNoteInput struct {
-	Title    string `json:"title"`
-	Content  string `json:"content"`
-	IsPublic bool   `json:"is_public"`
-	FolderID int    `json:"folder_id"`
+	Title    string    `json:"title" validate:"required,max=255"`
+	Content  string    `json:"content" validate:"max=1000000"`
+	Format   string    `json:"format" validate:"oneof=plain markdown html"`
+	IsPublic bool      `json:"is_public"`
+	FolderID int       `json:"folder_id"`
+	Tags     *[]string `json:"tags" validate:"max=20,dive,required,max=50"`
+}
+
+type UpdateNoteTagsInput struct {
+	Add    []string `json:"add" validate:"max=20,dive,required,max=50"`
+	Remove []string `json:"remove" validate:"max=20,dive,required,max=50"`
+}
+
+type RenameTagInput struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type MergeTagInput struct {
+	Into string `json:"into" validate:"required,max=50"`
+}
+
+type ShareInput struct {
+	Email      string `json:"email" validate:"required,email,max=255"`
+	Permission string `json:"permission" validate:"required,oneof=viewer commenter editor"`
+}
+
+type CreateShareLinkInput struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password" validate:"max=72"`
 }
@@ -0,0 +1,70 @@
+package note
+
+import (
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+)
+
+const (
+	SortRelevance = "relevance"
+	SortUpdatedAt = "updated_at"
+	SortCreatedAt = "created_at"
+	SortTitle     = "title"
+)
+
+var Sorts = []string{SortRelevance, SortUpdatedAt, SortCreatedAt, SortTitle}
+
+var sortColumns = map[string]string{
+	SortUpdatedAt: "n.updated_at",
+	SortCreatedAt: "n.created_at",
+	SortTitle:     "n.title",
+}
+
+// pageClause returns the condition seeking past the page's cursor and the
+// ORDER BY/LIMIT for the page's sort. Relevance is paged by offset since the
+// score is computed per query.
+func pageClause(page helper.Page) (string, []any, string, error) {
+	column, ok := sortColumns[page.Sort]
+	if !ok {
+		return "TRUE", nil, page.OffsetLimit("score DESC, n.updated_at DESC, n.id DESC"), nil
+	}
+
+	var value any = page.Cursor.Value
+	if page.Sort != SortTitle && page.Cursor != (helper.Cursor{}) {
+		parsed, err := time.Parse(time.RFC3339Nano, page.Cursor.Value)
+		if err != nil {
+			return "", nil, "", helper.ErrInvalidCursor
+		}
+
+		value = parsed
+	}
+
+	condition, args, orderLimit := page.Keyset(column, "n.id", value)
+
+	return condition, args, orderLimit, nil
+}
+
+// paginate trims the extra row fetched by pageClause and builds the
+// pagination meta from the last note kept.
+func paginate(notes []Note, page helper.Page) ([]Note, helper.Pagination) {
+	if len(notes) <= page.Limit {
+		return notes, page.Next(len(notes), helper.Cursor{})
+	}
+
+	last := notes[page.Limit-1]
+
+	var cursor helper.Cursor
+	switch page.Sort {
+	case SortUpdatedAt:
+		cursor = helper.Cursor{Value: last.UpdatedAt.Format(time.RFC3339Nano), ID: last.ID}
+	case SortCreatedAt:
+		cursor = helper.Cursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+	case SortTitle:
+		cursor = helper.Cursor{Value: last.Title, ID: last.ID}
+	default:
+		cursor = page.NextOffset()
+	}
+
+	return notes[:page.Limit], page.Next(len(notes), cursor)
+}
@@ -0,0 +1,40 @@
+package note
+
+const (
+	PermissionViewer    = "viewer"
+	PermissionCommenter = "commenter"
+	PermissionEditor    = "editor"
+	PermissionOwner     = "owner"
+)
+
+var permissionLevels = map[string]int{
+	PermissionViewer:    1,
+	PermissionCommenter: 2,
+	PermissionEditor:    3,
+	PermissionOwner:     4,
+}
+
+// IsValidPermission reports whether the permission can be granted to another user.
+func IsValidPermission(permission string) bool {
+	return permission == PermissionViewer || permission == PermissionCommenter || permission == PermissionEditor
+}
+
+// Allows reports whether holding permission is enough for required.
+func Allows(permission, required string) bool {
+	return permissionLevels[permission] >= permissionLevels[required]
+}
+
+// grantsQuery resolves every note shared with a user, directly or through a
+// shared ancestor folder, to the highest permission granted on it. It takes
+// the user ID twice and exposes a grants(note_id, permission) table.
+const grantsQuery = "WITH RECURSIVE shared_folders AS (" +
+	"SELECT f.id, s.permission FROM shares s JOIN folders f ON f.id = s.folder_id " +
+	"WHERE s.user_id = ? AND f.deleted_at IS NULL " +
+	"UNION ALL SELECT f.id, sf.permission FROM folders f JOIN shared_folders sf ON f.parent_id = sf.id WHERE f.deleted_at IS NULL" +
+	"), granted AS (" +
+	"SELECT note_id, permission FROM shares WHERE user_id = ? AND note_id IS NOT NULL " +
+	"UNION ALL SELECT n.id, sf.permission FROM notes n JOIN shared_folders sf ON n.folder_id = sf.id" +
+	"), grants AS (" +
+	"SELECT note_id, ELT(MAX(FIELD(permission, 'viewer', 'commenter', 'editor')), 'viewer', 'commenter', 'editor') AS permission " +
+	"FROM granted GROUP BY note_id" +
+	") "
@@ -0,0 +1,78 @@
+package note
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+const RenderHTML = "html"
+
+var (
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// The policy for user generated content keeps formatting, links, images
+	// and tables but drops scripts, styles, event handlers and iframes
+	sanitizer = bluemonday.UGCPolicy()
+)
+
+// RenderNote fills in the note's HTML when render asks for it. The content
+// is rendered according to the note's format and always sanitized, so the
+// HTML can be served to anyone.
+func RenderNote(note Note, render string) (Note, error) {
+	switch render {
+	case "":
+		return note, nil
+	case RenderHTML:
+	default:
+		return note, ErrInvalidRender
+	}
+
+	var rendered string
+
+	switch note.Format {
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(note.Content), &buf); err != nil {
+			return note, err
+		}
+		rendered = buf.String()
+	case FormatHTML:
+		rendered = note.Content
+	default:
+		rendered = renderPlain(note.Content)
+	}
+
+	note.HTML = sanitizer.Sanitize(rendered)
+
+	return note, nil
+}
+
+// renderPlain escapes the text and splits it into paragraphs on blank lines,
+// keeping single line breaks.
+func renderPlain(content string) string {
+	var b strings.Builder
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+
+	return b.String()
+}
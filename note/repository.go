@@ -5,40 +5,78 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
 )
 
 type Repository interface {
+	WithTx(tx *sql.Tx) Repository
 	FindByID(userID, id int) (Note, error)
-	FindAll(search string) ([]Note, error)
-	FindByUserID(userID int, search string) ([]Note, error)
-	FindByFolderID(userID, folderID int, search string) ([]Note, error)
+	FindAll(search SearchQuery, page helper.Page) ([]Note, error)
+	FindByUserID(userID int, search SearchQuery, page helper.Page) ([]Note, error)
+	FindByFolderID(userID, folderID int, search SearchQuery, page helper.Page) ([]Note, error)
+	FindAllByUserID(userID, afterID, limit int) ([]Note, error)
+	CountAll() (int, error)
+	CountByUserID(userID int) (int, error)
+	CountByFolderID(userID, folderID int) (int, error)
 	Save(note Note) (Note, error)
 	SaveWithFolderID(note Note) (Note, error)
 	Update(note Note) (Note, error)
 	UpdateWithFolderID(note Note, parentID any) (Note, error)
 	Delete(note Note) error
+	FindTrashedByUserID(userID int) ([]Note, error)
+	FindTrashedByID(userID, id int) (Note, error)
+	Restore(note Note) error
+	ForceDelete(note Note) error
+	ForceDeleteTrashedBefore(before time.Time) error
 	FindTagsByNoteIDs(noteIDs []int) ([]Tag, error)
-	FindTagsByName(tagNames []string) ([]Tag, error)
-	SaveTags(tags []Tag) (lastID int, err error)
+	FindTagsByName(userID int, tagNames []string) ([]Tag, error)
+	FindOrCreateTags(userID int, tagNames []string) ([]int, error)
 	SaveNoteTags(noteID int, tagIDs []int) error
+	SyncNoteTags(userID, noteID int, attach, detach []string) error
+	FindTagsByUserID(userID int) ([]Tag, error)
+	FindTagByUserID(userID int, name string) (Tag, error)
+	FindByTagID(userID, tagID int, page helper.Page) ([]Note, error)
+	UpdateTag(tag Tag) (Tag, error)
+	RetagNotes(fromTagID, toTagID int) error
+	DeleteTag(tag Tag) error
+	FindSharedByID(userID, id int) (Note, error)
+	FindSharedWithUser(userID int, page helper.Page) ([]Note, error)
+	FindSharesByNoteID(noteID int) ([]Share, error)
+	FindSharesByFolderID(folderID int) ([]Share, error)
+	SaveShare(share Share) (Share, error)
+	DeleteShare(share Share) error
+	FolderBelongsToUser(userID, folderID int) (bool, error)
+	FindShareLinksByNoteID(noteID int) ([]ShareLink, error)
+	FindShareLinkByTokenHash(tokenHash string) (ShareLink, error)
+	SaveShareLink(link ShareLink) (ShareLink, error)
+	DeleteShareLink(link ShareLink) error
+	FindRevisionsByNoteID(noteID int) ([]Revision, error)
+	FindRevisionByID(noteID, id int) (Revision, error)
+	SaveRevision(revision Revision) (Revision, error)
 }
 
 type repository struct {
-	db *sql.DB
+	db transaction.DBTX
 }
 
 func NewRepository(db *sql.DB) *repository {
 	return &repository{db}
 }
 
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{tx}
+}
+
 func (r *repository) FindByID(userID int, id int) (Note, error) {
 	var note Note
 
-	query := "SELECT n.id, n.title, n.content, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
-		"n.created_at, n.updated_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id WHERE n.user_id = ? AND n.id = ?"
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id WHERE n.user_id = ? AND n.id = ? AND n.deleted_at IS NULL"
 
 	err := r.db.QueryRow(query, userID, id).Scan(
-		&note.ID, &note.Title, &note.Content, &note.IsPublic, &note.UserID, &note.UserName,
+		&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
 		&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt,
 	)
 	if err != nil {
@@ -48,23 +86,34 @@ func (r *repository) FindByID(userID int, id int) (Note, error) {
 	return note, nil
 }
 
-func (r *repository) FindAll(search string) ([]Note, error) {
+func (r *repository) FindAll(search SearchQuery, page helper.Page) ([]Note, error) {
 	var notes []Note
 
-	query := "SELECT n.id, n.title, n.content, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
-		"n.created_at, n.updated_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id WHERE n.is_public = 1 AND n.title LIKE ?"
+	condition, conditionArgs, score, scoreArgs := search.clause()
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return notes, err
+	}
+
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, " + score + " AS score FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"WHERE n.is_public = 1 AND n.deleted_at IS NULL AND " + condition + " AND " + pageCondition + " " + orderLimit
+
+	fields := append(scoreArgs, conditionArgs...)
+	fields = append(fields, pageArgs...)
 
-	rows, err := r.db.Query(query, search+"%")
+	rows, err := r.db.Query(query, fields...)
 	if err != nil {
 		return notes, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var note Note
 
 		if err := rows.Scan(
-			&note.ID, &note.Title, &note.Content, &note.IsPublic, &note.UserID, &note.UserName,
-			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt,
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.Score,
 		); err != nil {
 			return notes, err
 		}
@@ -75,23 +124,35 @@ func (r *repository) FindAll(search string) ([]Note, error) {
 	return notes, nil
 }
 
-func (r *repository) FindByUserID(userID int, search string) ([]Note, error) {
+func (r *repository) FindByUserID(userID int, search SearchQuery, page helper.Page) ([]Note, error) {
 	var notes []Note
 
-	query := "SELECT n.id, n.title, n.content, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
-		"n.created_at, n.updated_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id WHERE n.user_id = ? AND n.title LIKE ?"
+	condition, conditionArgs, score, scoreArgs := search.clause()
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return notes, err
+	}
+
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, " + score + " AS score FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"WHERE n.user_id = ? AND n.deleted_at IS NULL AND " + condition + " AND " + pageCondition + " " + orderLimit
+
+	fields := append(scoreArgs, userID)
+	fields = append(fields, conditionArgs...)
+	fields = append(fields, pageArgs...)
 
-	rows, err := r.db.Query(query, userID, search+"%")
+	rows, err := r.db.Query(query, fields...)
 	if err != nil {
 		return notes, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var note Note
 
 		if err := rows.Scan(
-			&note.ID, &note.Title, &note.Content, &note.IsPublic, &note.UserID, &note.UserName,
-			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt,
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.Score,
 		); err != nil {
 			return notes, err
 		}
@@ -102,22 +163,65 @@ func (r *repository) FindByUserID(userID int, search string) ([]Note, error) {
 	return notes, nil
 }
 
-func (r *repository) FindByFolderID(userID int, folderID int, search string) ([]Note, error) {
+func (r *repository) FindByFolderID(userID int, folderID int, search SearchQuery, page helper.Page) ([]Note, error) {
 	var notes []Note
 
-	query := "SELECT n.id, n.title, n.content, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
-		"n.created_at, n.updated_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id WHERE n.user_id = ? AND n.folder_id = ? AND n.title LIKE ?"
+	condition, conditionArgs, score, scoreArgs := search.clause()
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return notes, err
+	}
+
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, " + score + " AS score FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"WHERE n.user_id = ? AND n.folder_id = ? AND n.deleted_at IS NULL AND " + condition + " AND " + pageCondition + " " + orderLimit
 
-	rows, err := r.db.Query(query, userID, folderID, search+"%")
+	fields := append(scoreArgs, userID, folderID)
+	fields = append(fields, conditionArgs...)
+	fields = append(fields, pageArgs...)
+
+	rows, err := r.db.Query(query, fields...)
 	if err != nil {
 		return notes, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var note Note
 
 		if err := rows.Scan(
-			&note.ID, &note.Title, &note.Content, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.Score,
+		); err != nil {
+			return notes, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// FindAllByUserID returns the notes the user owns outside the trash in id
+// order, up to limit of them with an id above afterID, without searching.
+func (r *repository) FindAllByUserID(userID, afterID, limit int) ([]Note, error) {
+	var notes []Note
+
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"WHERE n.user_id = ? AND n.deleted_at IS NULL AND n.id > ? ORDER BY n.id LIMIT ?"
+
+	rows, err := r.db.Query(query, userID, afterID, limit)
+	if err != nil {
+		return notes, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var note Note
+
+		if err := rows.Scan(
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
 			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt,
 		); err != nil {
 			return notes, err
@@ -129,10 +233,37 @@ func (r *repository) FindByFolderID(userID int, folderID int, search string) ([]
 	return notes, nil
 }
 
+func (r *repository) CountAll() (int, error) {
+	var total int
+
+	query := "SELECT COUNT(*) FROM notes WHERE is_public = 1 AND deleted_at IS NULL"
+
+	err := r.db.QueryRow(query).Scan(&total)
+	return total, err
+}
+
+func (r *repository) CountByUserID(userID int) (int, error) {
+	var total int
+
+	query := "SELECT COUNT(*) FROM notes WHERE user_id = ? AND deleted_at IS NULL"
+
+	err := r.db.QueryRow(query, userID).Scan(&total)
+	return total, err
+}
+
+func (r *repository) CountByFolderID(userID, folderID int) (int, error) {
+	var total int
+
+	query := "SELECT COUNT(*) FROM notes WHERE user_id = ? AND folder_id = ? AND deleted_at IS NULL"
+
+	err := r.db.QueryRow(query, userID, folderID).Scan(&total)
+	return total, err
+}
+
 func (r *repository) Save(note Note) (Note, error) {
 	query := "INSERT INTO notes SET " +
-		"title = ?, content = ?, is_public = ?, user_id = ?, created_at = now(), updated_at = now()"
-	res, err := r.db.Exec(query, note.Title, note.Content, note.IsPublic, note.UserID)
+		"title = ?, content = ?, format = ?, is_public = ?, user_id = ?, created_at = now(), updated_at = now()"
+	res, err := r.db.Exec(query, note.Title, note.Content, note.Format, note.IsPublic, note.UserID)
 	if err != nil {
 		return note, err
 	}
@@ -151,9 +282,9 @@ func (r *repository) Save(note Note) (Note, error) {
 
 func (r *repository) SaveWithFolderID(note Note) (Note, error) {
 	query := "INSERT INTO notes SET " +
-		"title = ?, content = ?, is_public = ?, user_id = ?, folder_id = ?, created_at = now(), updated_at = now()"
+		"title = ?, content = ?, format = ?, is_public = ?, user_id = ?, folder_id = ?, created_at = now(), updated_at = now()"
 
-	res, err := r.db.Exec(query, note.Title, note.Content, note.IsPublic, note.UserID, note.FolderID)
+	res, err := r.db.Exec(query, note.Title, note.Content, note.Format, note.IsPublic, note.UserID, note.FolderID)
 	if err != nil {
 		return note, err
 	}
@@ -172,11 +303,11 @@ func (r *repository) SaveWithFolderID(note Note) (Note, error) {
 
 func (r *repository) Update(note Note) (Note, error) {
 	query := "UPDATE notes SET " +
-		"title = ?, content = ?, is_public = ?, updated_at = NOW() " +
+		"title = ?, content = ?, format = ?, is_public = ?, updated_at = NOW() " +
 		"WHERE id = ?"
 
 	note.UpdatedAt = time.Now()
-	_, err := r.db.Exec(query, note.Title, note.Content, note.IsPublic, note.ID)
+	_, err := r.db.Exec(query, note.Title, note.Content, note.Format, note.IsPublic, note.ID)
 	if err != nil {
 		return note, err
 	}
@@ -186,11 +317,11 @@ func (r *repository) Update(note Note) (Note, error) {
 
 func (r *repository) UpdateWithFolderID(note Note, parentID any) (Note, error) {
 	query := "UPDATE notes SET " +
-		"title = ?, content = ?, is_public = ?, folder_id = ?, updated_at = NOW() " +
+		"title = ?, content = ?, format = ?, is_public = ?, folder_id = ?, updated_at = NOW() " +
 		"WHERE id = ?"
 
 	note.UpdatedAt = time.Now()
-	_, err := r.db.Exec(query, note.Title, note.Content, note.IsPublic, parentID, note.ID)
+	_, err := r.db.Exec(query, note.Title, note.Content, note.Format, note.IsPublic, parentID, note.ID)
 	if err != nil {
 		return note, err
 	}
@@ -198,13 +329,88 @@ func (r *repository) UpdateWithFolderID(note Note, parentID any) (Note, error) {
 	return note, nil
 }
 
+// Delete stamps deleted_at in UTC, like folders do and the trash sweeper
+// expects, whatever the server's time zone.
 func (r *repository) Delete(note Note) error {
+	query := "UPDATE notes SET deleted_at = UTC_TIMESTAMP() WHERE id = ?"
+
+	_, err := r.db.Exec(query, note.ID)
+	return err
+}
+
+func (r *repository) FindTrashedByUserID(userID int) ([]Note, error) {
+	var notes []Note
+
+	// Notes trashed together with their folder are listed under that folder instead
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, n.deleted_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"WHERE n.user_id = ? AND n.deleted_at IS NOT NULL AND (f.id IS NULL OR f.deleted_at IS NULL OR f.deleted_at <> n.deleted_at) " +
+		"ORDER BY n.deleted_at DESC"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return notes, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var note Note
+
+		if err := rows.Scan(
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.DeletedAt,
+		); err != nil {
+			return notes, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+func (r *repository) FindTrashedByID(userID, id int) (Note, error) {
+	var note Note
+
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, n.deleted_at FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"WHERE n.user_id = ? AND n.id = ? AND n.deleted_at IS NOT NULL"
+
+	err := r.db.QueryRow(query, userID, id).Scan(
+		&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+		&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.DeletedAt,
+	)
+	if err != nil {
+		return note, err
+	}
+
+	return note, nil
+}
+
+func (r *repository) Restore(note Note) error {
+	// A note whose folder is still in the trash is moved back to the root
+	query := "UPDATE notes n LEFT JOIN folders f ON n.folder_id = f.id " +
+		"SET n.folder_id = IF(f.deleted_at IS NULL, n.folder_id, NULL), n.deleted_at = NULL, n.updated_at = NOW() " +
+		"WHERE n.id = ?"
+
+	_, err := r.db.Exec(query, note.ID)
+	return err
+}
+
+func (r *repository) ForceDelete(note Note) error {
 	query := "DELETE FROM notes WHERE id = ?"
 
 	_, err := r.db.Exec(query, note.ID)
 	return err
 }
 
+func (r *repository) ForceDeleteTrashedBefore(before time.Time) error {
+	query := "DELETE FROM notes WHERE deleted_at IS NOT NULL AND deleted_at < ?"
+
+	_, err := r.db.Exec(query, before)
+	return err
+}
+
 func (r *repository) FindTagsByNoteIDs(noteIDs []int) ([]Tag, error) {
 	var tags []Tag
 
@@ -224,6 +430,7 @@ func (r *repository) FindTagsByNoteIDs(noteIDs []int) ([]Tag, error) {
 	if err != nil {
 		return tags, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var tag Tag
@@ -240,14 +447,14 @@ func (r *repository) FindTagsByNoteIDs(noteIDs []int) ([]Tag, error) {
 	return tags, nil
 }
 
-func (r *repository) FindTagsByName(tagNames []string) ([]Tag, error) {
+func (r *repository) FindTagsByName(userID int, tagNames []string) ([]Tag, error) {
 	var tags []Tag
 
-	query := "SELECT t.id, t.name, t.created_at, t.updated_at " +
-		"FROM tags t WHERE t.name IN (%s)"
+	query := "SELECT t.id, t.name, t.user_id, t.created_at, t.updated_at " +
+		"FROM tags t WHERE t.user_id = ? AND t.name IN (%s)"
 
 	questionMarks := []string{}
-	fields := []any{}
+	fields := []any{userID}
 	for _, tagName := range tagNames {
 		questionMarks = append(questionMarks, "?")
 		fields = append(fields, tagName)
@@ -258,12 +465,13 @@ func (r *repository) FindTagsByName(tagNames []string) ([]Tag, error) {
 	if err != nil {
 		return tags, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var tag Tag
 
 		if err := rows.Scan(
-			&tag.ID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt,
+			&tag.ID, &tag.Name, &tag.UserID, &tag.CreatedAt, &tag.UpdatedAt,
 		); err != nil {
 			return tags, err
 		}
@@ -274,32 +482,54 @@ func (r *repository) FindTagsByName(tagNames []string) ([]Tag, error) {
 	return tags, nil
 }
 
-func (r *repository) SaveTags(tags []Tag) (lastID int, err error) {
-	query := "INSERT INTO tags (name, created_at, updated_at) VALUES "
+// FindOrCreateTags returns the IDs of the user's named tags, inserting the
+// missing ones one by one. The lookup compares names the way the column's
+// collation does, ignoring case and accents, so a name it misses can still
+// match an existing tag, or one a concurrent request just created. The
+// insert then resolves to that tag's ID instead of failing on the unique key.
+// Names that resolve to the same tag yield its ID once.
+func (r *repository) FindOrCreateTags(userID int, tagNames []string) ([]int, error) {
+	var tagIDs []int
 
-	questionMarks := []string{}
-	fields := []any{}
+	tags, err := r.FindTagsByName(userID, tagNames)
+	if err != nil {
+		return tagIDs, err
+	}
 
+	existing := map[string]int{}
 	for _, tag := range tags {
-		questionMarks = append(questionMarks, "(?, now(), now())")
-		fields = append(fields, tag.Name)
+		existing[strings.ToLower(tag.Name)] = tag.ID
 	}
 
-	query += strings.Join(questionMarks, ",")
+	seen := map[int]bool{}
 
-	res, err := r.db.Exec(query, fields...)
-	if err != nil {
-		return 0, err
-	}
+	for _, tagName := range tagNames {
+		id, ok := existing[strings.ToLower(tagName)]
+		if !ok {
+			query := "INSERT INTO tags SET name = ?, user_id = ?, created_at = NOW(), updated_at = NOW() " +
+				"ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)"
+
+			res, err := r.db.Exec(query, tagName, userID)
+			if err != nil {
+				return tagIDs, err
+			}
+
+			insertID, err := res.LastInsertId()
+			if err != nil {
+				return tagIDs, err
+			}
+
+			id = int(insertID)
+			existing[strings.ToLower(tagName)] = id
+		}
 
-	id, err := res.LastInsertId()
-	if err != nil {
-		return 0, err
+		if !seen[id] {
+			seen[id] = true
+			tagIDs = append(tagIDs, id)
+		}
 	}
 
-	lastID = int(id)
-
-	return
+	return tagIDs, nil
 }
 
 func (r *repository) SaveNoteTags(noteID int, tagIDs []int) error {
@@ -322,3 +552,473 @@ func (r *repository) SaveNoteTags(noteID int, tagIDs []int) error {
 
 	return nil
 }
+
+// SyncNoteTags links the attach tags to the note, creating the ones that don't
+// exist yet, and unlinks the detach tags. Run it inside a transaction.
+func (r *repository) SyncNoteTags(userID, noteID int, attach, detach []string) error {
+	if len(attach) > 0 {
+		tagIDs, err := r.FindOrCreateTags(userID, attach)
+		if err != nil {
+			return err
+		}
+
+		questionMarks := []string{}
+		fields := []any{noteID}
+		for _, id := range tagIDs {
+			questionMarks = append(questionMarks, "?")
+			fields = append(fields, id)
+		}
+		fields = append(fields, noteID)
+
+		query := fmt.Sprintf("INSERT INTO note_tags (note_id, tag_id, created_at, updated_at) "+
+			"SELECT ?, t.id, NOW(), NOW() FROM tags t WHERE t.id IN (%s) "+
+			"AND NOT EXISTS (SELECT 1 FROM note_tags nt WHERE nt.note_id = ? AND nt.tag_id = t.id)",
+			strings.Join(questionMarks, ","))
+
+		if _, err := r.db.Exec(query, fields...); err != nil {
+			return err
+		}
+	}
+
+	if len(detach) > 0 {
+		questionMarks := []string{}
+		fields := []any{noteID}
+		for _, name := range detach {
+			questionMarks = append(questionMarks, "?")
+			fields = append(fields, name)
+		}
+
+		query := fmt.Sprintf("DELETE nt FROM note_tags nt JOIN tags t ON nt.tag_id = t.id "+
+			"WHERE nt.note_id = ? AND t.name IN (%s)", strings.Join(questionMarks, ","))
+
+		if _, err := r.db.Exec(query, fields...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) FindTagsByUserID(userID int) ([]Tag, error) {
+	var tags []Tag
+
+	query := "SELECT t.id, t.name, t.user_id, COUNT(n.id), t.created_at, t.updated_at " +
+		"FROM tags t LEFT JOIN note_tags nt ON t.id = nt.tag_id LEFT JOIN notes n ON nt.note_id = n.id AND n.deleted_at IS NULL " +
+		"WHERE t.user_id = ? GROUP BY t.id, t.name, t.user_id, t.created_at, t.updated_at ORDER BY t.name"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return tags, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag Tag
+
+		if err := rows.Scan(
+			&tag.ID, &tag.Name, &tag.UserID, &tag.NoteCount, &tag.CreatedAt, &tag.UpdatedAt,
+		); err != nil {
+			return tags, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func (r *repository) FindTagByUserID(userID int, name string) (Tag, error) {
+	var tag Tag
+
+	query := "SELECT t.id, t.name, t.user_id, COUNT(n.id), t.created_at, t.updated_at " +
+		"FROM tags t LEFT JOIN note_tags nt ON t.id = nt.tag_id LEFT JOIN notes n ON nt.note_id = n.id AND n.deleted_at IS NULL " +
+		"WHERE t.user_id = ? AND t.name = ? GROUP BY t.id, t.name, t.user_id, t.created_at, t.updated_at"
+
+	err := r.db.QueryRow(query, userID, name).Scan(
+		&tag.ID, &tag.Name, &tag.UserID, &tag.NoteCount, &tag.CreatedAt, &tag.UpdatedAt,
+	)
+	if err != nil {
+		return tag, err
+	}
+
+	return tag, nil
+}
+
+func (r *repository) FindByTagID(userID, tagID int, page helper.Page) ([]Note, error) {
+	var notes []Note
+
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return notes, err
+	}
+
+	query := "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, 0 AS score FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"JOIN note_tags nt ON nt.note_id = n.id " +
+		"WHERE n.user_id = ? AND nt.tag_id = ? AND n.deleted_at IS NULL AND " + pageCondition + " " + orderLimit
+
+	rows, err := r.db.Query(query, append([]any{userID, tagID}, pageArgs...)...)
+	if err != nil {
+		return notes, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var note Note
+
+		if err := rows.Scan(
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.Score,
+		); err != nil {
+			return notes, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+func (r *repository) UpdateTag(tag Tag) (Tag, error) {
+	query := "UPDATE tags SET name = ?, updated_at = NOW() WHERE id = ?"
+
+	tag.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query, tag.Name, tag.ID)
+	if err != nil {
+		return tag, err
+	}
+
+	return tag, nil
+}
+
+// RetagNotes moves every note from one tag to another, dropping the links of
+// notes that already carry the target tag.
+func (r *repository) RetagNotes(fromTagID, toTagID int) error {
+	query := "DELETE nt FROM note_tags nt " +
+		"JOIN note_tags existing ON existing.note_id = nt.note_id AND existing.tag_id = ? " +
+		"WHERE nt.tag_id = ?"
+
+	if _, err := r.db.Exec(query, toTagID, fromTagID); err != nil {
+		return err
+	}
+
+	query = "UPDATE note_tags SET tag_id = ?, updated_at = NOW() WHERE tag_id = ?"
+
+	_, err := r.db.Exec(query, toTagID, fromTagID)
+	return err
+}
+
+func (r *repository) DeleteTag(tag Tag) error {
+	query := "DELETE FROM tags WHERE id = ?"
+
+	_, err := r.db.Exec(query, tag.ID)
+	return err
+}
+
+// FindSharedByID finds a note another user shared with userID, along with the
+// highest permission granted on it.
+func (r *repository) FindSharedByID(userID, id int) (Note, error) {
+	var note Note
+
+	query := grantsQuery + "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, g.permission FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"JOIN grants g ON g.note_id = n.id WHERE n.id = ? AND n.user_id <> ? AND n.deleted_at IS NULL"
+
+	err := r.db.QueryRow(query, userID, userID, id, userID).Scan(
+		&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+		&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.Permission,
+	)
+	if err != nil {
+		return note, err
+	}
+
+	return note, nil
+}
+
+func (r *repository) FindSharedWithUser(userID int, page helper.Page) ([]Note, error) {
+	var notes []Note
+
+	pageCondition, pageArgs, orderLimit, err := pageClause(page)
+	if err != nil {
+		return notes, err
+	}
+
+	query := grantsQuery + "SELECT n.id, n.title, n.content, n.format, n.is_public, n.user_id, u.name, COALESCE(n.folder_id, 0), COALESCE(f.name, ''), " +
+		"n.created_at, n.updated_at, 0 AS score, g.permission FROM notes n LEFT JOIN folders f ON n.folder_id = f.id AND n.user_id = f.user_id JOIN users u ON n.user_id = u.id " +
+		"JOIN grants g ON g.note_id = n.id WHERE n.user_id <> ? AND n.deleted_at IS NULL AND " + pageCondition + " " + orderLimit
+
+	rows, err := r.db.Query(query, append([]any{userID, userID, userID}, pageArgs...)...)
+	if err != nil {
+		return notes, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var note Note
+
+		if err := rows.Scan(
+			&note.ID, &note.Title, &note.Content, &note.Format, &note.IsPublic, &note.UserID, &note.UserName,
+			&note.FolderID, &note.FolderName, &note.CreatedAt, &note.UpdatedAt, &note.Score, &note.Permission,
+		); err != nil {
+			return notes, err
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+func (r *repository) FindSharesByNoteID(noteID int) ([]Share, error) {
+	query := "SELECT s.id, s.owner_id, s.user_id, u.name, u.email, COALESCE(s.note_id, 0), COALESCE(s.folder_id, 0), " +
+		"s.permission, s.created_at, s.updated_at FROM shares s JOIN users u ON s.user_id = u.id WHERE s.note_id = ?"
+
+	return r.findShares(query, noteID)
+}
+
+func (r *repository) FindSharesByFolderID(folderID int) ([]Share, error) {
+	query := "SELECT s.id, s.owner_id, s.user_id, u.name, u.email, COALESCE(s.note_id, 0), COALESCE(s.folder_id, 0), " +
+		"s.permission, s.created_at, s.updated_at FROM shares s JOIN users u ON s.user_id = u.id WHERE s.folder_id = ?"
+
+	return r.findShares(query, folderID)
+}
+
+func (r *repository) findShares(query string, id int) ([]Share, error) {
+	var shares []Share
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return shares, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var share Share
+
+		if err := rows.Scan(
+			&share.ID, &share.OwnerID, &share.UserID, &share.UserName, &share.UserEmail,
+			&share.NoteID, &share.FolderID, &share.Permission, &share.CreatedAt, &share.UpdatedAt,
+		); err != nil {
+			return shares, err
+		}
+
+		shares = append(shares, share)
+	}
+
+	return shares, nil
+}
+
+// SaveShare grants the permission on the share's note or folder, replacing the
+// permission the user already had on it.
+func (r *repository) SaveShare(share Share) (Share, error) {
+	var noteID, folderID any
+	if share.NoteID > 0 {
+		noteID = share.NoteID
+	}
+	if share.FolderID > 0 {
+		folderID = share.FolderID
+	}
+
+	query := "INSERT INTO shares SET " +
+		"owner_id = ?, user_id = ?, note_id = ?, folder_id = ?, permission = ?, created_at = NOW(), updated_at = NOW() " +
+		"ON DUPLICATE KEY UPDATE permission = VALUES(permission), updated_at = NOW()"
+
+	_, err := r.db.Exec(query, share.OwnerID, share.UserID, noteID, folderID, share.Permission)
+	if err != nil {
+		return share, err
+	}
+
+	share.UpdatedAt = time.Now()
+
+	return share, nil
+}
+
+func (r *repository) DeleteShare(share Share) error {
+	if share.FolderID > 0 {
+		query := "DELETE FROM shares WHERE folder_id = ? AND user_id = ?"
+
+		_, err := r.db.Exec(query, share.FolderID, share.UserID)
+		return err
+	}
+
+	query := "DELETE FROM shares WHERE note_id = ? AND user_id = ?"
+
+	_, err := r.db.Exec(query, share.NoteID, share.UserID)
+	return err
+}
+
+func (r *repository) FolderBelongsToUser(userID, folderID int) (bool, error) {
+	var count int
+
+	query := "SELECT COUNT(*) FROM folders WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+
+	if err := r.db.QueryRow(query, folderID, userID).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *repository) FindShareLinksByNoteID(noteID int) ([]ShareLink, error) {
+	var links []ShareLink
+
+	query := "SELECT id, note_id, user_id, token_hash, COALESCE(password_hash, ''), expires_at, created_at, updated_at " +
+		"FROM share_links WHERE note_id = ? ORDER BY id DESC"
+
+	rows, err := r.db.Query(query, noteID)
+	if err != nil {
+		return links, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link ShareLink
+		var expiresAt sql.NullTime
+
+		if err := rows.Scan(
+			&link.ID, &link.NoteID, &link.UserID, &link.TokenHash, &link.PasswordHash,
+			&expiresAt, &link.CreatedAt, &link.UpdatedAt,
+		); err != nil {
+			return links, err
+		}
+		link.ExpiresAt = expiresAt.Time
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+func (r *repository) FindShareLinkByTokenHash(tokenHash string) (ShareLink, error) {
+	var link ShareLink
+	var expiresAt sql.NullTime
+
+	query := "SELECT id, note_id, user_id, token_hash, COALESCE(password_hash, ''), expires_at, created_at, updated_at " +
+		"FROM share_links WHERE token_hash = ?"
+
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&link.ID, &link.NoteID, &link.UserID, &link.TokenHash, &link.PasswordHash,
+		&expiresAt, &link.CreatedAt, &link.UpdatedAt,
+	)
+	if err != nil {
+		return link, err
+	}
+	link.ExpiresAt = expiresAt.Time
+
+	return link, nil
+}
+
+func (r *repository) SaveShareLink(link ShareLink) (ShareLink, error) {
+	var passwordHash, expiresAt any
+	if link.PasswordHash != "" {
+		passwordHash = link.PasswordHash
+	}
+	if !link.ExpiresAt.IsZero() {
+		expiresAt = link.ExpiresAt.UTC()
+	}
+
+	query := "INSERT INTO share_links SET " +
+		"note_id = ?, user_id = ?, token_hash = ?, password_hash = ?, expires_at = ?, created_at = NOW(), updated_at = NOW()"
+
+	res, err := r.db.Exec(query, link.NoteID, link.UserID, link.TokenHash, passwordHash, expiresAt)
+	if err != nil {
+		return link, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return link, err
+	}
+
+	link.ID = int(id)
+	link.CreatedAt = time.Now()
+	link.UpdatedAt = time.Now()
+
+	return link, nil
+}
+
+func (r *repository) DeleteShareLink(link ShareLink) error {
+	query := "DELETE FROM share_links WHERE id = ? AND note_id = ?"
+
+	res, err := r.db.Exec(query, link.ID, link.NoteID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) FindRevisionsByNoteID(noteID int) ([]Revision, error) {
+	var revisions []Revision
+
+	query := "SELECT id, note_id, title, content, format, created_at, updated_at " +
+		"FROM note_revisions WHERE note_id = ? ORDER BY id DESC"
+
+	rows, err := r.db.Query(query, noteID)
+	if err != nil {
+		return revisions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision Revision
+
+		if err := rows.Scan(
+			&revision.ID, &revision.NoteID, &revision.Title, &revision.Content, &revision.Format,
+			&revision.CreatedAt, &revision.UpdatedAt,
+		); err != nil {
+			return revisions, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+func (r *repository) FindRevisionByID(noteID, id int) (Revision, error) {
+	var revision Revision
+
+	query := "SELECT id, note_id, title, content, format, created_at, updated_at " +
+		"FROM note_revisions WHERE note_id = ? AND id = ?"
+
+	err := r.db.QueryRow(query, noteID, id).Scan(
+		&revision.ID, &revision.NoteID, &revision.Title, &revision.Content, &revision.Format,
+		&revision.CreatedAt, &revision.UpdatedAt,
+	)
+	if err != nil {
+		return revision, err
+	}
+
+	return revision, nil
+}
+
+func (r *repository) SaveRevision(revision Revision) (Revision, error) {
+	query := "INSERT INTO note_revisions SET " +
+		"note_id = ?, title = ?, content = ?, format = ?, created_at = NOW(), updated_at = NOW()"
+
+	res, err := r.db.Exec(query, revision.NoteID, revision.Title, revision.Content, revision.Format)
+	if err != nil {
+		return revision, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return revision, err
+	}
+
+	revision.ID = int(id)
+	revision.CreatedAt = time.Now()
+	revision.UpdatedAt = time.Now()
+
+	return revision, nil
+}
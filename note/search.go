@@ -0,0 +1,216 @@
+package note
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const snippetLength = 160
+
+// SearchQuery is a parsed search string. Bare words and "quoted phrases" are
+// matched against title, content and tag names; words or phrases prefixed with
+// a minus sign exclude notes that contain them.
+type SearchQuery struct {
+	Terms    []string
+	Phrases  []string
+	Excludes []string
+}
+
+var booleanOperators = strings.NewReplacer(
+	"+", " ", "-", " ", "<", " ", ">", " ", "(", " ", ")", " ",
+	"~", " ", "*", " ", "\"", " ", "@", " ",
+)
+
+func ParseSearchQuery(search string) SearchQuery {
+	var query SearchQuery
+
+	search = strings.TrimSpace(search)
+	for len(search) > 0 {
+		exclude := false
+		if search[0] == '-' {
+			exclude = true
+			search = search[1:]
+		}
+
+		var token string
+		phrase := len(search) > 0 && search[0] == '"'
+		if phrase {
+			search = search[1:]
+			end := strings.IndexByte(search, '"')
+			if end < 0 {
+				end = len(search)
+			}
+
+			token = search[:end]
+			search = search[min(end+1, len(search)):]
+		} else {
+			end := strings.IndexAny(search, " \t\n")
+			if end < 0 {
+				end = len(search)
+			}
+
+			token = search[:end]
+			search = search[end:]
+		}
+		search = strings.TrimSpace(search)
+
+		token = strings.Join(strings.Fields(booleanOperators.Replace(token)), " ")
+		if token == "" {
+			continue
+		}
+
+		switch {
+		case exclude:
+			query.Excludes = append(query.Excludes, token)
+		case phrase && strings.Contains(token, " "):
+			query.Phrases = append(query.Phrases, token)
+		default:
+			query.Terms = append(query.Terms, token)
+		}
+	}
+
+	return query
+}
+
+func (q SearchQuery) IsEmpty() bool {
+	return len(q.Terms) == 0 && len(q.Phrases) == 0 && len(q.Excludes) == 0
+}
+
+// includes returns every word and phrase the notes should match.
+func (q SearchQuery) includes() []string {
+	return append(append([]string{}, q.Terms...), q.Phrases...)
+}
+
+// clause builds the SQL condition and relevance score for the query, to be
+// used against the notes table aliased as n.
+func (q SearchQuery) clause() (condition string, conditionArgs []any, score string, scoreArgs []any) {
+	conditions := []string{}
+	scores := []string{}
+
+	if includes := q.includes(); len(includes) > 0 {
+		expression := booleanExpression(includes)
+		tagQuestionMarks, tagFields := tagNames(includes)
+
+		conditions = append(conditions, fmt.Sprintf(
+			"(MATCH(n.title, n.content) AGAINST (? IN BOOLEAN MODE) OR EXISTS ("+
+				"SELECT 1 FROM note_tags nt JOIN tags t ON nt.tag_id = t.id WHERE nt.note_id = n.id AND t.name IN (%s)))",
+			tagQuestionMarks,
+		))
+		conditionArgs = append(conditionArgs, expression)
+		conditionArgs = append(conditionArgs, tagFields...)
+
+		// Title matches weigh more than content matches, and an exact tag match
+		// weighs more than either
+		scores = append(scores, fmt.Sprintf(
+			"MATCH(n.title) AGAINST (? IN BOOLEAN MODE) * 2 + MATCH(n.title, n.content) AGAINST (? IN BOOLEAN MODE) + ("+
+				"SELECT COUNT(*) FROM note_tags nt JOIN tags t ON nt.tag_id = t.id WHERE nt.note_id = n.id AND t.name IN (%s)) * 3",
+			tagQuestionMarks,
+		))
+		scoreArgs = append(scoreArgs, expression, expression)
+		scoreArgs = append(scoreArgs, tagFields...)
+	}
+
+	if len(q.Excludes) > 0 {
+		expression := booleanExpression(q.Excludes)
+		tagQuestionMarks, tagFields := tagNames(q.Excludes)
+
+		conditions = append(conditions, fmt.Sprintf(
+			"NOT (MATCH(n.title, n.content) AGAINST (? IN BOOLEAN MODE) OR EXISTS ("+
+				"SELECT 1 FROM note_tags nt JOIN tags t ON nt.tag_id = t.id WHERE nt.note_id = n.id AND t.name IN (%s)))",
+			tagQuestionMarks,
+		))
+		conditionArgs = append(conditionArgs, expression)
+		conditionArgs = append(conditionArgs, tagFields...)
+	}
+
+	if len(conditions) == 0 {
+		conditions = append(conditions, "TRUE")
+	}
+
+	if len(scores) == 0 {
+		scores = append(scores, "0")
+	}
+
+	return strings.Join(conditions, " AND "), conditionArgs, strings.Join(scores, " + "), scoreArgs
+}
+
+// booleanExpression turns words into prefix matches and phrases into quoted
+// phrases for MySQL's boolean full-text mode.
+func booleanExpression(tokens []string) string {
+	parts := []string{}
+	for _, token := range tokens {
+		if strings.Contains(token, " ") {
+			parts = append(parts, `"`+token+`"`)
+		} else {
+			parts = append(parts, token+"*")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func tagNames(tokens []string) (string, []any) {
+	questionMarks := []string{}
+	fields := []any{}
+	for _, token := range tokens {
+		questionMarks = append(questionMarks, "?")
+		fields = append(fields, token)
+	}
+
+	return strings.Join(questionMarks, ","), fields
+}
+
+// Highlight returns an HTML-escaped excerpt of text around the first match of
+// the query, with every match wrapped in <mark> tags.
+func Highlight(text string, q SearchQuery) string {
+	includes := q.includes()
+	if len(includes) == 0 {
+		return ""
+	}
+
+	patterns := []string{}
+	for _, token := range includes {
+		patterns = append(patterns, regexp.QuoteMeta(token))
+	}
+	// Words are prefix matches in the database, so only highlight word starts
+	pattern := regexp.MustCompile(`(?i)\b(?:` + strings.Join(patterns, "|") + ")")
+
+	start, end := 0, len(text)
+	if loc := pattern.FindStringIndex(text); loc != nil {
+		start = max(loc[0]-snippetLength/3, 0)
+	}
+	end = min(start+snippetLength, len(text))
+
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	excerpt := text[start:end]
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString("…")
+	}
+
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(excerpt, -1) {
+		builder.WriteString(html.EscapeString(excerpt[last:loc[0]]))
+		builder.WriteString("<mark>")
+		builder.WriteString(html.EscapeString(excerpt[loc[0]:loc[1]]))
+		builder.WriteString("</mark>")
+		last = loc[1]
+	}
+	builder.WriteString(html.EscapeString(excerpt[last:]))
+
+	if end < len(text) {
+		builder.WriteString("…")
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,168 @@
+package note
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		want   SearchQuery
+	}{
+		{"empty", "", SearchQuery{}},
+		{"only spaces", "   \t ", SearchQuery{}},
+		{"words", "go  notes", SearchQuery{Terms: []string{"go", "notes"}}},
+		{"phrase", `"meeting notes" go`, SearchQuery{Terms: []string{"go"}, Phrases: []string{"meeting notes"}}},
+		{"single word phrase is a term", `"go"`, SearchQuery{Terms: []string{"go"}}},
+		{"unterminated phrase", `"meeting notes`, SearchQuery{Phrases: []string{"meeting notes"}}},
+		{"excludes", `go -draft -"old stuff"`, SearchQuery{Terms: []string{"go"}, Excludes: []string{"draft", "old stuff"}}},
+		{"boolean operators are dropped", `+go* (notes) ~x @3 <a>`, SearchQuery{Terms: []string{"go", "notes", "x", "3", "a"}}},
+		{"operators only", `+ - * ""`, SearchQuery{}},
+		{"operator inside a word splits it", `c++ foo-bar`, SearchQuery{Terms: []string{"c", "foo bar"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSearchQuery(tt.search); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSearchQuery(%q) = %#v, want %#v", tt.search, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBooleanExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   string
+	}{
+		{"no tokens", nil, ""},
+		{"words are prefix matches", []string{"go", "notes"}, "go* notes*"},
+		{"phrases are quoted", []string{"meeting notes", "go"}, `"meeting notes" go*`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := booleanExpression(tt.tokens); got != tt.want {
+				t.Errorf("booleanExpression(%q) = %q, want %q", tt.tokens, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchQueryClause(t *testing.T) {
+	tests := []struct {
+		name          string
+		search        string
+		wantCondition []string
+		wantArgs      []any
+		wantScoreArgs []any
+	}{
+		{
+			name:          "empty",
+			search:        "",
+			wantCondition: []string{"TRUE"},
+		},
+		{
+			name:          "includes",
+			search:        `go "meeting notes"`,
+			wantCondition: []string{"(MATCH(n.title, n.content) AGAINST (? IN BOOLEAN MODE) OR EXISTS (", "t.name IN (?,?)))"},
+			wantArgs:      []any{`go* "meeting notes"`, "go", "meeting notes"},
+			wantScoreArgs: []any{`go* "meeting notes"`, `go* "meeting notes"`, "go", "meeting notes"},
+		},
+		{
+			name:          "excludes only",
+			search:        "-draft",
+			wantCondition: []string{"NOT (MATCH(n.title, n.content) AGAINST (? IN BOOLEAN MODE)", "t.name IN (?)))"},
+			wantArgs:      []any{"draft*", "draft"},
+		},
+		{
+			name:          "includes and excludes",
+			search:        "go -draft",
+			wantCondition: []string{") AND NOT ("},
+			wantArgs:      []any{"go*", "go", "draft*", "draft"},
+			wantScoreArgs: []any{"go*", "go*", "go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args, score, scoreArgs := ParseSearchQuery(tt.search).clause()
+
+			for _, part := range tt.wantCondition {
+				if !strings.Contains(condition, part) {
+					t.Errorf("condition %q doesn't contain %q", condition, part)
+				}
+			}
+
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("condition args = %#v, want %#v", args, tt.wantArgs)
+			}
+
+			if !reflect.DeepEqual(scoreArgs, tt.wantScoreArgs) {
+				t.Errorf("score args = %#v, want %#v", scoreArgs, tt.wantScoreArgs)
+			}
+
+			if placeholders := strings.Count(condition, "?"); placeholders != len(args) {
+				t.Errorf("condition has %d placeholders for %d args", placeholders, len(args))
+			}
+
+			if placeholders := strings.Count(score, "?"); placeholders != len(scoreArgs) {
+				t.Errorf("score has %d placeholders for %d args", placeholders, len(scoreArgs))
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("filler ", 40)
+
+	tests := []struct {
+		name   string
+		text   string
+		search string
+		want   string
+	}{
+		{"empty query", "some <b>text</b>", "", ""},
+		{"excludes only", "some text", "-some", ""},
+		{"empty text", "", "go", ""},
+		{"marks every match", "Go is go", "go", "<mark>Go</mark> is <mark>go</mark>"},
+		{"marks word starts only", "ago going", "go", "ago <mark>go</mark>ing"},
+		{"escapes html around matches", `<script>alert("go")</script>`, "go", `&lt;script&gt;alert(&#34;<mark>go</mark>&#34;)&lt;/script&gt;`},
+		{"escapes html inside matches", "a b&c", "b&c", "a <mark>b&amp;c</mark>"},
+		{"escapes html without a match", "<i>nothing</i>", "go", "&lt;i&gt;nothing&lt;/i&gt;"},
+		{"phrases", "meeting  notes and meeting notes", `"meeting notes"`, "meeting  notes and <mark>meeting notes</mark>"},
+		{"regexp characters are literal", "a.b axb", "a.b", "<mark>a.b</mark> axb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Highlight(tt.text, ParseSearchQuery(tt.search)); got != tt.want {
+				t.Errorf("Highlight(%q, %q) = %q, want %q", tt.text, tt.search, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("excerpt around a late match", func(t *testing.T) {
+		got := Highlight(long+"golang "+long, ParseSearchQuery("golang"))
+
+		if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+			t.Errorf("excerpt isn't marked as cut on both ends: %q", got)
+		}
+
+		if !strings.Contains(got, "<mark>golang</mark>") {
+			t.Errorf("excerpt misses the match: %q", got)
+		}
+	})
+
+	t.Run("excerpt doesn't split runes", func(t *testing.T) {
+		got := Highlight(strings.Repeat("é", 200)+" go", ParseSearchQuery("go"))
+
+		if strings.ContainsRune(got, '�') || !strings.HasPrefix(got, "…é") {
+			t.Errorf("excerpt was cut inside a rune: %q", got)
+		}
+	})
+}
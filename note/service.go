@@ -1,111 +1,217 @@
 package note
 
 import (
+	"database/sql"
 	"errors"
+	"strings"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Service interface {
-	PublicNotes(search string) ([]Note, error)
-	FindNotes(userID int, folderID int, search string) ([]Note, error)
+	PublicNotes(search string, page helper.Page) ([]Note, helper.Pagination, error)
+	FindNotes(userID int, folderID int, search string, page helper.Page) ([]Note, helper.Pagination, error)
 	FindNote(userID int, noteID int) (Note, error)
 	CreateNote(input CreateNoteInput, userID int) (Note, error)
 	UpdateNote(input UpdateNoteInput, userID, noteID int) (Note, error)
+	UpdateNoteTags(input UpdateNoteTagsInput, userID, noteID int) (Note, error)
 	DeleteNote(userID int, noteID int) error
+	FindTrashedNotes(userID int) ([]Note, error)
+	RestoreNote(userID, noteID int) (Note, error)
+	PurgeNote(userID, noteID int) error
+	PurgeTrashedNotes(before time.Time) error
+	FindRevisions(userID, noteID int) ([]Revision, error)
+	FindRevision(userID, noteID, revisionID int) (Revision, []DiffLine, error)
+	RestoreRevision(userID, noteID, revisionID int) (Note, error)
+	FindTags(userID int) ([]Tag, error)
+	FindNotesByTag(userID int, name string, page helper.Page) ([]Note, helper.Pagination, error)
+	RenameTag(userID int, name string, input RenameTagInput) (Tag, error)
+	MergeTag(userID int, name string, input MergeTagInput) (Tag, error)
+	DeleteTag(userID int, name string) error
+	FindSharedNotes(userID int, page helper.Page) ([]Note, helper.Pagination, error)
+	FindNoteShares(ownerID, noteID int) ([]Share, error)
+	ShareNote(input ShareInput, ownerID, noteID, granteeID int) (Share, error)
+	UnshareNote(ownerID, noteID, granteeID int) error
+	FindFolderShares(ownerID, folderID int) ([]Share, error)
+	ShareFolder(input ShareInput, ownerID, folderID, granteeID int) (Share, error)
+	UnshareFolder(ownerID, folderID, granteeID int) error
+	FindShareLinks(ownerID, noteID int) ([]ShareLink, error)
+	CreateShareLink(input CreateShareLinkInput, ownerID, noteID int) (ShareLink, error)
+	RevokeShareLink(ownerID, noteID, linkID int) error
+	FindNoteByShareLink(token, password string) (Note, error)
 }
 
 type service struct {
-	repository Repository
+	repository   Repository
+	transactions transaction.Manager
 }
 
-func NewService(repository Repository) *service {
-	return &service{repository}
+func NewService(repository Repository, transactions transaction.Manager) *service {
+	return &service{repository, transactions}
 }
 
-func (s *service) PublicNotes(search string) ([]Note, error) {
+func (s *service) PublicNotes(search string, page helper.Page) ([]Note, helper.Pagination, error) {
 	var notes []Note
+	var pagination helper.Pagination
 
-	notes, err := s.repository.FindAll(search)
+	query, page, err := searchPage(search, page)
 	if err != nil {
-		return notes, err
+		return notes, pagination, err
 	}
 
-	if len(notes) > 0 {
-		var noteIDs []int
-
-		for _, note := range notes {
-			noteIDs = append(noteIDs, note.ID)
-		}
+	notes, err = s.repository.FindAll(query, page)
+	if err != nil {
+		return notes, pagination, err
+	}
+	notes, pagination = paginate(notes, page)
 
-		tags, err := s.repository.FindTagsByNoteIDs(noteIDs)
+	// Counting every match of a search costs as much as the search itself
+	if query.IsEmpty() {
+		total, err := s.repository.CountAll()
 		if err != nil {
-			return notes, err
+			return notes, pagination, err
 		}
+		pagination.Total = &total
+	}
 
-		mappedTags := map[int][]Tag{}
-		for _, tag := range tags {
-			mappedTags[tag.NoteID] = append(mappedTags[tag.NoteID], tag)
-		}
+	if err := attachTags(s.repository, notes); err != nil {
+		return notes, pagination, err
+	}
 
-		for i, note := range notes {
-			if existingTag, ok := mappedTags[note.ID]; ok {
-				notes[i].Tags = existingTag
-			}
-		}
+	for i, note := range notes {
+		notes[i].Snippet = Highlight(note.Content, query)
 	}
 
-	return notes, nil
+	return notes, pagination, nil
 }
 
-func (s *service) FindNotes(userID int, folderID int, search string) ([]Note, error) {
+func (s *service) FindNotes(userID int, folderID int, search string, page helper.Page) ([]Note, helper.Pagination, error) {
 	var notes []Note
+	var pagination helper.Pagination
 
 	if userID == 0 {
-		return notes, errors.New("no user available on this session")
+		return notes, pagination, helper.ErrNoSession
 	}
 
-	var err error
+	query, page, err := searchPage(search, page)
+	if err != nil {
+		return notes, pagination, err
+	}
+
+	var total int
 
 	if folderID != 0 {
-		notes, err = s.repository.FindByFolderID(userID, folderID, search)
+		notes, err = s.repository.FindByFolderID(userID, folderID, query, page)
 		if err != nil {
-			return notes, err
+			return notes, pagination, err
+		}
+
+		if query.IsEmpty() {
+			total, err = s.repository.CountByFolderID(userID, folderID)
 		}
 	} else {
-		notes, err = s.repository.FindByUserID(userID, search)
+		notes, err = s.repository.FindByUserID(userID, query, page)
 		if err != nil {
-			return notes, err
+			return notes, pagination, err
+		}
+
+		if query.IsEmpty() {
+			total, err = s.repository.CountByUserID(userID)
 		}
 	}
+	if err != nil {
+		return notes, pagination, err
+	}
 
-	if len(notes) > 0 {
-		var noteIDs []int
+	notes, pagination = paginate(notes, page)
+	if query.IsEmpty() {
+		pagination.Total = &total
+	}
 
-		for _, note := range notes {
-			noteIDs = append(noteIDs, note.ID)
-		}
+	if err := attachTags(s.repository, notes); err != nil {
+		return notes, pagination, err
+	}
 
-		tags, err := s.repository.FindTagsByNoteIDs(noteIDs)
-		if err != nil {
-			return notes, err
-		}
+	for i, note := range notes {
+		notes[i].Snippet = Highlight(note.Content, query)
+	}
 
-		mappedTags := map[int][]Tag{}
-		for _, tag := range tags {
-			mappedTags[tag.NoteID] = append(mappedTags[tag.NoteID], tag)
-		}
+	return notes, pagination, nil
+}
 
-		for i, note := range notes {
-			if existingTag, ok := mappedTags[note.ID]; ok {
-				notes[i].Tags = existingTag
-			}
-		}
+// searchPage parses the search string and defaults the page to relevance
+// order when searching, or to the most recently updated notes otherwise.
+func searchPage(search string, page helper.Page) (SearchQuery, helper.Page, error) {
+	query := ParseSearchQuery(search)
+
+	sort := SortUpdatedAt
+	if !query.IsEmpty() {
+		sort = SortRelevance
+	}
+
+	page, err := page.WithDefaultSort(sort)
+
+	return query, page, err
+}
+
+// attachTags loads the tags of a page of notes with a single query.
+func attachTags(repository Repository, notes []Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	var noteIDs []int
+	for _, note := range notes {
+		noteIDs = append(noteIDs, note.ID)
+	}
+
+	tags, err := repository.FindTagsByNoteIDs(noteIDs)
+	if err != nil {
+		return err
+	}
+
+	mappedTags := map[int][]Tag{}
+	for _, tag := range tags {
+		mappedTags[tag.NoteID] = append(mappedTags[tag.NoteID], tag)
+	}
+
+	for i, note := range notes {
+		notes[i].Tags = mappedTags[note.ID]
 	}
 
-	return notes, nil
+	return nil
+}
+
+// findAccessibleNote finds a note the user either owns or was granted at
+// least the required permission on.
+func findAccessibleNote(repository Repository, userID, noteID int, required string) (Note, error) {
+	note, err := repository.FindByID(userID, noteID)
+	if err == nil {
+		note.Permission = PermissionOwner
+		return note, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) || required == PermissionOwner {
+		return note, helper.NoRows(err, ErrNotFound)
+	}
+
+	note, err = repository.FindSharedByID(userID, noteID)
+	if err != nil {
+		return note, helper.NoRows(err, ErrNotFound)
+	}
+
+	if !Allows(note.Permission, required) {
+		return note, ErrForbidden
+	}
+
+	return note, nil
 }
 
 func (s *service) FindNote(userID int, noteID int) (Note, error) {
-	note, err := s.repository.FindByID(userID, noteID)
+	note, err := findAccessibleNote(s.repository, userID, noteID, PermissionViewer)
 	if err != nil {
 		return note, err
 	}
@@ -123,115 +229,688 @@ func (s *service) CreateNote(input CreateNoteInput, userID int) (Note, error) {
 
 	note.Title = input.Title
 	note.Content = input.Content
+	note.Format = input.Format
 	note.IsPublic = input.IsPublic
 	note.FolderID = input.FolderID
 	note.UserID = userID
 
-	var err error
-	if note.FolderID == 0 {
-		note, err = s.repository.Save(note)
+	if note.Format == "" {
+		note.Format = FormatPlain
+	}
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		var err error
+		if note.FolderID == 0 {
+			note, err = repository.Save(note)
+		} else {
+			if err := checkFolderPlacement(repository, userID, note.FolderID); err != nil {
+				return err
+			}
+
+			note, err = repository.SaveWithFolderID(note)
+		}
 		if err != nil {
-			return note, err
+			return err
 		}
-	} else {
-		note, err = s.repository.SaveWithFolderID(note)
+
+		tagNames := normalizeTagNames(input.Tags)
+		if len(tagNames) == 0 {
+			return nil
+		}
+
+		tagIDs, err := repository.FindOrCreateTags(userID, tagNames)
 		if err != nil {
-			return note, err
+			return err
 		}
-	}
 
-	if len(input.Tags) == 0 {
-		return note, nil
+		if err := repository.SaveNoteTags(note.ID, tagIDs); err != nil {
+			return err
+		}
+
+		note.Tags, err = repository.FindTagsByNoteIDs([]int{note.ID})
+		return err
+	})
+
+	return note, err
+}
+
+func (s *service) UpdateNote(input UpdateNoteInput, userID, noteID int) (Note, error) {
+	var newNote Note
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		oldNote, err := findAccessibleNote(repository, userID, noteID, PermissionEditor)
+		if err != nil {
+			return err
+		}
+
+		// Visibility and placement stay in the owner's hands
+		if oldNote.Permission != PermissionOwner {
+			input.IsPublic = oldNote.IsPublic
+			input.FolderID = oldNote.FolderID
+		}
+
+		formatChanged := input.Format != "" && input.Format != oldNote.Format
+		if oldNote.Title != input.Title || oldNote.Content != input.Content || formatChanged {
+			if _, err := repository.SaveRevision(Revision{
+				NoteID:  oldNote.ID,
+				Title:   oldNote.Title,
+				Content: oldNote.Content,
+				Format:  oldNote.Format,
+			}); err != nil {
+				return err
+			}
+		}
+
+		oldNote.Title = input.Title
+		oldNote.Content = input.Content
+		if input.Format != "" {
+			oldNote.Format = input.Format
+		}
+		oldNote.IsPublic = input.IsPublic
+		oldNote.FolderID = input.FolderID
+
+		oldNote.Tags, err = repository.FindTagsByNoteIDs([]int{noteID})
+		if err != nil {
+			return err
+		}
+
+		if input.Tags != nil {
+			attach, detach := diffTags(oldNote.Tags, normalizeTagNames(*input.Tags))
+
+			if len(attach) > 0 || len(detach) > 0 {
+				if err := repository.SyncNoteTags(oldNote.UserID, noteID, attach, detach); err != nil {
+					return err
+				}
+
+				oldNote.Tags, err = repository.FindTagsByNoteIDs([]int{noteID})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if oldNote.FolderID == 0 {
+			newNote, err = repository.Update(oldNote)
+			return err
+		}
+
+		var folderID any
+		if oldNote.FolderID > 0 {
+			if err := checkFolderPlacement(repository, oldNote.UserID, oldNote.FolderID); err != nil {
+				return err
+			}
+
+			folderID = oldNote.FolderID
+		} else {
+			folderID = nil
+			oldNote.FolderID = 0
+			oldNote.FolderName = ""
+		}
+
+		newNote, err = repository.UpdateWithFolderID(oldNote, folderID)
+		return err
+	})
+
+	return newNote, err
+}
+
+func (s *service) UpdateNoteTags(input UpdateNoteTagsInput, userID, noteID int) (Note, error) {
+	var note Note
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		var err error
+		note, err = findAccessibleNote(repository, userID, noteID, PermissionEditor)
+		if err != nil {
+			return err
+		}
+
+		attach := normalizeTagNames(input.Add)
+		detach := normalizeTagNames(input.Remove)
+
+		if len(attach) > 0 || len(detach) > 0 {
+			if err := repository.SyncNoteTags(note.UserID, noteID, attach, detach); err != nil {
+				return err
+			}
+		}
+
+		note.Tags, err = repository.FindTagsByNoteIDs([]int{noteID})
+		return err
+	})
+
+	return note, err
+}
+
+func (s *service) DeleteNote(userID int, noteID int) error {
+	note, err := s.repository.FindByID(userID, noteID)
+	if err != nil {
+		return helper.NoRows(err, ErrNotFound)
 	}
 
-	mappingInputTags := map[string]bool{}
-	for _, tag := range input.Tags {
-		mappingInputTags[tag] = true
+	return s.repository.Delete(note)
+}
+
+func (s *service) FindTrashedNotes(userID int) ([]Note, error) {
+	var notes []Note
+
+	if userID == 0 {
+		return notes, helper.ErrNoSession
 	}
 
-	existingTags, err := s.repository.FindTagsByName(input.Tags)
+	return s.repository.FindTrashedByUserID(userID)
+}
+
+func (s *service) RestoreNote(userID, noteID int) (Note, error) {
+	note, err := s.repository.FindTrashedByID(userID, noteID)
 	if err != nil {
+		return note, helper.NoRows(err, ErrNotFound)
+	}
+
+	if err := s.repository.Restore(note); err != nil {
 		return note, err
 	}
-	note.Tags = append(note.Tags, existingTags...)
 
-	var tagsIDs []int
-	for _, tag := range existingTags {
-		delete(mappingInputTags, tag.Name)
-		tagsIDs = append(tagsIDs, tag.ID)
+	return s.FindNote(userID, noteID)
+}
+
+func (s *service) PurgeNote(userID, noteID int) error {
+	note, err := s.repository.FindTrashedByID(userID, noteID)
+	if err != nil {
+		return helper.NoRows(err, ErrNotFound)
+	}
+
+	return s.repository.ForceDelete(note)
+}
+
+func (s *service) PurgeTrashedNotes(before time.Time) error {
+	return s.repository.ForceDeleteTrashedBefore(before)
+}
+
+func (s *service) FindRevisions(userID, noteID int) ([]Revision, error) {
+	var revisions []Revision
+
+	note, err := findAccessibleNote(s.repository, userID, noteID, PermissionViewer)
+	if err != nil {
+		return revisions, err
 	}
 
-	var newTags []Tag
-	for tag := range mappingInputTags {
-		newTags = append(newTags, Tag{Name: tag})
+	return s.repository.FindRevisionsByNoteID(note.ID)
+}
+
+func (s *service) FindRevision(userID, noteID, revisionID int) (Revision, []DiffLine, error) {
+	note, err := findAccessibleNote(s.repository, userID, noteID, PermissionViewer)
+	if err != nil {
+		return Revision{}, nil, err
+	}
+
+	revision, err := s.repository.FindRevisionByID(note.ID, revisionID)
+	if err != nil {
+		return revision, nil, helper.NoRows(err, ErrRevisionNotFound)
 	}
-	note.Tags = append(note.Tags, newTags...)
 
-	if len(newTags) > 0 {
-		lastID, err := s.repository.SaveTags(newTags)
+	return revision, DiffLines(revision.Content, note.Content), nil
+}
+
+func (s *service) RestoreRevision(userID, noteID, revisionID int) (Note, error) {
+	var note Note
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		var err error
+		note, err = findAccessibleNote(repository, userID, noteID, PermissionEditor)
 		if err != nil {
-			return note, err
+			return err
 		}
 
-		for i := 0; i < len(newTags); i++ {
-			tagsIDs = append(tagsIDs, lastID+i)
+		revision, err := repository.FindRevisionByID(note.ID, revisionID)
+		if err != nil {
+			return helper.NoRows(err, ErrRevisionNotFound)
+		}
+
+		// Keep the current version around so the restore itself can be undone
+		if _, err := repository.SaveRevision(Revision{
+			NoteID:  note.ID,
+			Title:   note.Title,
+			Content: note.Content,
+			Format:  note.Format,
+		}); err != nil {
+			return err
 		}
+
+		note.Title = revision.Title
+		note.Content = revision.Content
+		note.Format = revision.Format
+
+		note, err = repository.Update(note)
+		if err != nil {
+			return err
+		}
+
+		note.Tags, err = repository.FindTagsByNoteIDs([]int{noteID})
+		return err
+	})
+
+	return note, err
+}
+
+func (s *service) FindTags(userID int) ([]Tag, error) {
+	var tags []Tag
+
+	if userID == 0 {
+		return tags, helper.ErrNoSession
 	}
 
-	if err := s.repository.SaveNoteTags(note.ID, tagsIDs); err != nil {
-		return note, err
+	return s.repository.FindTagsByUserID(userID)
+}
+
+func (s *service) FindNotesByTag(userID int, name string, page helper.Page) ([]Note, helper.Pagination, error) {
+	var notes []Note
+	var pagination helper.Pagination
+
+	tag, err := s.repository.FindTagByUserID(userID, NormalizeTagName(name))
+	if err != nil {
+		return notes, pagination, helper.NoRows(err, ErrTagNotFound)
 	}
 
-	return note, nil
+	page, err = page.WithDefaultSort(SortUpdatedAt)
+	if err != nil {
+		return notes, pagination, err
+	}
+
+	notes, err = s.repository.FindByTagID(userID, tag.ID, page)
+	if err != nil {
+		return notes, pagination, err
+	}
+	notes, pagination = paginate(notes, page)
+
+	total := tag.NoteCount
+	pagination.Total = &total
+
+	if err := attachTags(s.repository, notes); err != nil {
+		return notes, pagination, err
+	}
+
+	return notes, pagination, nil
 }
 
-func (s *service) UpdateNote(input UpdateNoteInput, userID, noteID int) (Note, error) {
-	oldNote, err := s.repository.FindByID(userID, noteID)
+func (s *service) RenameTag(userID int, name string, input RenameTagInput) (Tag, error) {
+	tag, err := s.repository.FindTagByUserID(userID, NormalizeTagName(name))
+	if err != nil {
+		return tag, helper.NoRows(err, ErrTagNotFound)
+	}
+
+	newName := NormalizeTagName(input.Name)
+	if newName == "" {
+		return tag, ErrEmptyTagName
+	}
+
+	if newName == tag.Name {
+		return tag, nil
+	}
+
+	// Renaming onto a name the user already has folds the two tags together
+	existingTags, err := s.repository.FindTagsByName(userID, []string{newName})
+	if err != nil {
+		return tag, err
+	}
+
+	if len(existingTags) > 0 {
+		return s.mergeTags(userID, tag, existingTags[0])
+	}
+
+	tag.Name = newName
+
+	return s.repository.UpdateTag(tag)
+}
+
+func (s *service) MergeTag(userID int, name string, input MergeTagInput) (Tag, error) {
+	source, err := s.repository.FindTagByUserID(userID, NormalizeTagName(name))
+	if err != nil {
+		return source, helper.NoRows(err, ErrTagNotFound)
+	}
+
+	target, err := s.repository.FindTagByUserID(userID, NormalizeTagName(input.Into))
+	if err != nil {
+		return target, helper.NoRows(err, ErrTagNotFound)
+	}
+
+	if source.ID == target.ID {
+		return target, ErrMergeTagIntoSelf
+	}
+
+	return s.mergeTags(userID, source, target)
+}
+
+func (s *service) mergeTags(userID int, source, target Tag) (Tag, error) {
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		if err := repository.RetagNotes(source.ID, target.ID); err != nil {
+			return err
+		}
+
+		return repository.DeleteTag(source)
+	})
+	if err != nil {
+		return target, err
+	}
+
+	return s.repository.FindTagByUserID(userID, target.Name)
+}
+
+func (s *service) DeleteTag(userID int, name string) error {
+	tag, err := s.repository.FindTagByUserID(userID, NormalizeTagName(name))
+	if err != nil {
+		return helper.NoRows(err, ErrTagNotFound)
+	}
+
+	return s.repository.DeleteTag(tag)
+}
+
+func (s *service) FindSharedNotes(userID int, page helper.Page) ([]Note, helper.Pagination, error) {
+	var notes []Note
+	var pagination helper.Pagination
+
+	if userID == 0 {
+		return notes, pagination, helper.ErrNoSession
+	}
+
+	page, err := page.WithDefaultSort(SortUpdatedAt)
+	if err != nil {
+		return notes, pagination, err
+	}
+
+	notes, err = s.repository.FindSharedWithUser(userID, page)
+	if err != nil {
+		return notes, pagination, err
+	}
+	notes, pagination = paginate(notes, page)
+
+	if err := attachTags(s.repository, notes); err != nil {
+		return notes, pagination, err
+	}
+
+	return notes, pagination, nil
+}
+
+func (s *service) FindNoteShares(ownerID, noteID int) ([]Share, error) {
+	var shares []Share
+
+	note, err := s.repository.FindByID(ownerID, noteID)
+	if err != nil {
+		return shares, helper.NoRows(err, ErrNotFound)
+	}
+
+	return s.repository.FindSharesByNoteID(note.ID)
+}
+
+func (s *service) ShareNote(input ShareInput, ownerID, noteID, granteeID int) (Share, error) {
+	var share Share
+
+	note, err := s.repository.FindByID(ownerID, noteID)
+	if err != nil {
+		return share, helper.NoRows(err, ErrNotFound)
+	}
+
+	if !IsValidPermission(input.Permission) {
+		return share, ErrInvalidPermission
+	}
+
+	if granteeID == ownerID {
+		return share, ErrShareWithOwner
+	}
+
+	share.OwnerID = ownerID
+	share.UserID = granteeID
+	share.NoteID = note.ID
+	share.Permission = input.Permission
+
+	// No user has the email. It's answered like a share all the same, so
+	// sharing can't be used to find out who's registered
+	if granteeID == 0 {
+		return share, nil
+	}
+
+	return s.repository.SaveShare(share)
+}
+
+func (s *service) UnshareNote(ownerID, noteID, granteeID int) error {
+	note, err := s.repository.FindByID(ownerID, noteID)
+	if err != nil {
+		return helper.NoRows(err, ErrNotFound)
+	}
+
+	return s.repository.DeleteShare(Share{NoteID: note.ID, UserID: granteeID})
+}
+
+func (s *service) FindFolderShares(ownerID, folderID int) ([]Share, error) {
+	var shares []Share
+
+	if err := s.checkFolderOwner(ownerID, folderID); err != nil {
+		return shares, err
+	}
+
+	return s.repository.FindSharesByFolderID(folderID)
+}
+
+func (s *service) ShareFolder(input ShareInput, ownerID, folderID, granteeID int) (Share, error) {
+	var share Share
+
+	if err := s.checkFolderOwner(ownerID, folderID); err != nil {
+		return share, err
+	}
+
+	if !IsValidPermission(input.Permission) {
+		return share, ErrInvalidPermission
+	}
+
+	if granteeID == ownerID {
+		return share, ErrShareWithOwner
+	}
+
+	share.OwnerID = ownerID
+	share.UserID = granteeID
+	share.FolderID = folderID
+	share.Permission = input.Permission
+
+	// No user has the email. It's answered like a share all the same, so
+	// sharing can't be used to find out who's registered
+	if granteeID == 0 {
+		return share, nil
+	}
+
+	return s.repository.SaveShare(share)
+}
+
+func (s *service) UnshareFolder(ownerID, folderID, granteeID int) error {
+	if err := s.checkFolderOwner(ownerID, folderID); err != nil {
+		return err
+	}
+
+	return s.repository.DeleteShare(Share{FolderID: folderID, UserID: granteeID})
+}
+
+func (s *service) FindShareLinks(ownerID, noteID int) ([]ShareLink, error) {
+	var links []ShareLink
+
+	note, err := s.repository.FindByID(ownerID, noteID)
 	if err != nil {
-		return oldNote, err
+		return links, helper.NoRows(err, ErrNotFound)
 	}
 
-	oldNote.Title = input.Title
-	oldNote.Content = input.Content
-	oldNote.IsPublic = input.IsPublic
-	oldNote.FolderID = input.FolderID
+	return s.repository.FindShareLinksByNoteID(note.ID)
+}
 
-	oldNote.Tags, err = s.repository.FindTagsByNoteIDs([]int{noteID})
+func (s *service) CreateShareLink(input CreateShareLinkInput, ownerID, noteID int) (ShareLink, error) {
+	var link ShareLink
+
+	note, err := s.repository.FindByID(ownerID, noteID)
 	if err != nil {
-		return oldNote, err
+		return link, helper.NoRows(err, ErrNotFound)
 	}
 
-	if oldNote.FolderID == 0 {
-		newNote, err := s.repository.Update(oldNote)
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(time.Now()) {
+			return link, ErrExpiryInPast
+		}
+		link.ExpiresAt = *input.ExpiresAt
+	}
+
+	if input.Password != "" {
+		passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 		if err != nil {
-			return oldNote, err
+			return link, err
 		}
+		link.PasswordHash = string(passwordHash)
+	}
 
-		return newNote, nil
+	token, err := helper.NewToken()
+	if err != nil {
+		return link, err
 	}
 
-	var folderID any
-	if oldNote.FolderID > 0 {
-		folderID = oldNote.FolderID
-	} else {
-		folderID = nil
-		oldNote.FolderID = 0
-		oldNote.FolderName = ""
+	link.NoteID = note.ID
+	link.UserID = ownerID
+	link.TokenHash = helper.HashToken(token)
+
+	link, err = s.repository.SaveShareLink(link)
+	if err != nil {
+		return link, err
 	}
 
-	newNote, err := s.repository.UpdateWithFolderID(oldNote, folderID)
+	// The plain token is only ever returned here, right after it's created
+	link.Token = token
+
+	return link, nil
+}
+
+func (s *service) RevokeShareLink(ownerID, noteID, linkID int) error {
+	note, err := s.repository.FindByID(ownerID, noteID)
 	if err != nil {
-		return oldNote, err
+		return helper.NoRows(err, ErrNotFound)
 	}
 
-	return newNote, nil
+	err = s.repository.DeleteShareLink(ShareLink{ID: linkID, NoteID: note.ID})
+
+	return helper.NoRows(err, ErrShareLinkNotFound)
 }
 
-func (s *service) DeleteNote(userID int, noteID int) error {
-	note, err := s.repository.FindByID(userID, noteID)
+// FindNoteByShareLink resolves a public share link to its note. The note is
+// served whether or not it's public, as holding the link is what grants access.
+func (s *service) FindNoteByShareLink(token, password string) (Note, error) {
+	var note Note
+
+	link, err := s.repository.FindShareLinkByTokenHash(helper.HashToken(token))
+	if err != nil {
+		return note, helper.NoRows(err, ErrShareLinkNotFound)
+	}
+
+	if link.HasExpired(time.Now()) {
+		return note, ErrShareLinkNotFound
+	}
+
+	if link.HasPassword() {
+		if password == "" {
+			return note, ErrShareLinkPasswordRequired
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+			return note, ErrShareLinkWrongPassword
+		}
+	}
+
+	note, err = s.repository.FindByID(link.UserID, link.NoteID)
+	if err != nil {
+		return note, helper.NoRows(err, ErrShareLinkNotFound)
+	}
+
+	note.Tags, err = s.repository.FindTagsByNoteIDs([]int{note.ID})
+	if err != nil {
+		return note, err
+	}
+
+	return note, nil
+}
+
+// checkFolderPlacement makes sure a note is only put in one of its owner's
+// folders.
+func checkFolderPlacement(repository Repository, userID, folderID int) error {
+	owned, err := repository.FolderBelongsToUser(userID, folderID)
 	if err != nil {
 		return err
 	}
 
-	return s.repository.Delete(note)
+	if !owned {
+		return ErrFolderForbidden
+	}
+
+	return nil
+}
+
+func (s *service) checkFolderOwner(userID, folderID int) error {
+	owned, err := s.repository.FolderBelongsToUser(userID, folderID)
+	if err != nil {
+		return err
+	}
+
+	if !owned {
+		return ErrFolderNotFound
+	}
+
+	return nil
+}
+
+// NormalizeTagName lowercases the name and collapses its whitespace, so
+// "Work" and " work" end up as the same tag.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// normalizeTagNames normalizes the tag names and drops empty and repeated ones.
+func normalizeTagNames(names []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		name = NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+
+	return normalized
+}
+
+// diffTags compares the note's current tags with the wanted tag names.
+func diffTags(current []Tag, names []string) (attach, detach []string) {
+	wanted := map[string]bool{}
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	existing := map[string]bool{}
+	for _, tag := range current {
+		existing[tag.Name] = true
+
+		if !wanted[tag.Name] {
+			detach = append(detach, tag.Name)
+		}
+	}
+
+	for _, name := range names {
+		if !existing[name] {
+			attach = append(attach, name)
+		}
+	}
+
+	return attach, detach
 }
@@ -0,0 +1,21 @@
+package note
+
+import (
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+)
+
+var (
+	ErrShareLinkNotFound         = helper.NewError(helper.ErrNotFound, "share_link_not_found", "share link doesn't exist or has expired")
+	ErrShareLinkPasswordRequired = helper.NewError(helper.ErrUnauthorized, "share_link_password_required", "share link is protected by a password")
+	ErrShareLinkWrongPassword    = helper.NewError(helper.ErrUnauthorized, "share_link_wrong_password", "wrong share link password")
+)
+
+func (l ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+func (l ShareLink) HasExpired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
+}
@@ -0,0 +1,16 @@
+package session
+
+import "time"
+
+type Session struct {
+	ID           int
+	UserID       int
+	RefreshToken string
+	TokenHash    string
+	UserAgent    string
+	IPAddress    string
+	ExpiresAt    time.Time
+	LastUsedAt   time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
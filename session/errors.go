@@ -0,0 +1,8 @@
+package session
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var (
+	ErrNotFound            = helper.NewError(helper.ErrNotFound, "session_not_found", "session doesn't exist or has expired")
+	ErrInvalidRefreshToken = helper.NewError(helper.ErrUnauthorized, "invalid_refresh_token", "refresh token is invalid or expired")
+)
@@ -0,0 +1,48 @@
+package session
+
+import "time"
+
+type SessionFormatter struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type TokenFormatter struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func FormatSession(session Session, currentSessionID int) SessionFormatter {
+	return SessionFormatter{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		Current:    session.ID == currentSessionID,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+		CreatedAt:  session.CreatedAt,
+	}
+}
+
+func FormatSessions(sessions []Session, currentSessionID int) []SessionFormatter {
+	sessionFormatters := []SessionFormatter{}
+
+	for _, session := range sessions {
+		sessionFormatter := FormatSession(session, currentSessionID)
+		sessionFormatters = append(sessionFormatters, sessionFormatter)
+	}
+
+	return sessionFormatters
+}
+
+func FormatToken(token string, session Session) TokenFormatter {
+	return TokenFormatter{
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+	}
+}
@@ -0,0 +1,184 @@
+package session
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
+)
+
+type Repository interface {
+	WithTx(tx *sql.Tx) Repository
+	FindByID(userID, id int) (Session, error)
+	FindByUserID(userID int) ([]Session, error)
+	FindByTokenHash(tokenHash string) (Session, error)
+	FindByPreviousTokenHash(tokenHash string) (Session, error)
+	Save(session Session) (Session, error)
+	Rotate(session Session, previousTokenHash string) (Session, error)
+	Delete(session Session) error
+	DeleteByUserID(userID, exceptID int) error
+	DeleteExpiredBefore(before time.Time) error
+}
+
+type repository struct {
+	db transaction.DBTX
+}
+
+func NewRepository(db *sql.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{tx}
+}
+
+func (r *repository) FindByID(userID, id int) (Session, error) {
+	query := "SELECT id, user_id, token_hash, user_agent, ip_address, expires_at, last_used_at, created_at, updated_at " +
+		"FROM sessions WHERE user_id = ? AND id = ? AND expires_at > UTC_TIMESTAMP()"
+
+	return r.findOne(query, userID, id)
+}
+
+func (r *repository) FindByUserID(userID int) ([]Session, error) {
+	var sessions []Session
+
+	query := "SELECT id, user_id, token_hash, user_agent, ip_address, expires_at, last_used_at, created_at, updated_at " +
+		"FROM sessions WHERE user_id = ? AND expires_at > UTC_TIMESTAMP() ORDER BY last_used_at DESC"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return sessions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session Session
+
+		if err := rows.Scan(
+			&session.ID, &session.UserID, &session.TokenHash, &session.UserAgent, &session.IPAddress,
+			&session.ExpiresAt, &session.LastUsedAt, &session.CreatedAt, &session.UpdatedAt,
+		); err != nil {
+			return sessions, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (r *repository) FindByTokenHash(tokenHash string) (Session, error) {
+	query := "SELECT id, user_id, token_hash, user_agent, ip_address, expires_at, last_used_at, created_at, updated_at " +
+		"FROM sessions WHERE token_hash = ?"
+
+	return r.findOne(query, tokenHash)
+}
+
+func (r *repository) FindByPreviousTokenHash(tokenHash string) (Session, error) {
+	query := "SELECT id, user_id, token_hash, user_agent, ip_address, expires_at, last_used_at, created_at, updated_at " +
+		"FROM sessions WHERE previous_token_hash = ?"
+
+	return r.findOne(query, tokenHash)
+}
+
+func (r *repository) findOne(query string, args ...any) (Session, error) {
+	var session Session
+
+	err := r.db.QueryRow(query, args...).Scan(
+		&session.ID, &session.UserID, &session.TokenHash, &session.UserAgent, &session.IPAddress,
+		&session.ExpiresAt, &session.LastUsedAt, &session.CreatedAt, &session.UpdatedAt,
+	)
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (r *repository) Save(session Session) (Session, error) {
+	query := "INSERT INTO sessions SET " +
+		"user_id = ?, token_hash = ?, user_agent = ?, ip_address = ?, expires_at = ?, last_used_at = UTC_TIMESTAMP(), created_at = NOW(), updated_at = NOW()"
+
+	res, err := r.db.Exec(query, session.UserID, session.TokenHash, session.UserAgent, session.IPAddress, session.ExpiresAt.UTC())
+	if err != nil {
+		return session, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return session, err
+	}
+
+	session.ID = int(id)
+	session.LastUsedAt = time.Now()
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+
+	return session, nil
+}
+
+// Rotate swaps the session's refresh token, keeping the replaced one around
+// so a replay of it can be recognised. It fails with sql.ErrNoRows when
+// previousTokenHash is no longer the current token, i.e. when two refreshes
+// race for the same token.
+func (r *repository) Rotate(session Session, previousTokenHash string) (Session, error) {
+	query := "UPDATE sessions SET " +
+		"token_hash = ?, previous_token_hash = ?, user_agent = ?, ip_address = ?, expires_at = ?, last_used_at = UTC_TIMESTAMP(), updated_at = NOW() " +
+		"WHERE id = ? AND token_hash = ?"
+
+	res, err := r.db.Exec(query,
+		session.TokenHash, previousTokenHash, session.UserAgent, session.IPAddress, session.ExpiresAt.UTC(),
+		session.ID, previousTokenHash,
+	)
+	if err != nil {
+		return session, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return session, err
+	}
+
+	if affected == 0 {
+		return session, sql.ErrNoRows
+	}
+
+	session.LastUsedAt = time.Now()
+	session.UpdatedAt = time.Now()
+
+	return session, nil
+}
+
+func (r *repository) Delete(session Session) error {
+	query := "DELETE FROM sessions WHERE id = ? AND user_id = ?"
+
+	res, err := r.db.Exec(query, session.ID, session.UserID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteByUserID(userID, exceptID int) error {
+	query := "DELETE FROM sessions WHERE user_id = ? AND id <> ?"
+
+	_, err := r.db.Exec(query, userID, exceptID)
+	return err
+}
+
+func (r *repository) DeleteExpiredBefore(before time.Time) error {
+	query := "DELETE FROM sessions WHERE expires_at < ?"
+
+	_, err := r.db.Exec(query, before.UTC())
+	return err
+}
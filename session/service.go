@@ -0,0 +1,144 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+)
+
+type Service interface {
+	StartSession(userID int, userAgent, ipAddress string) (Session, error)
+	Refresh(input RefreshTokenInput, userAgent, ipAddress string) (Session, error)
+	FindSession(userID, sessionID int) (Session, error)
+	FindSessions(userID int) ([]Session, error)
+	RevokeSession(userID, sessionID int) error
+	RevokeOtherSessions(userID, currentSessionID int) error
+	PurgeExpiredSessions(before time.Time) error
+}
+
+type service struct {
+	repository Repository
+	refreshTTL time.Duration
+}
+
+func NewService(repository Repository, refreshTTL time.Duration) *service {
+	return &service{repository, refreshTTL}
+}
+
+// StartSession opens a session for a freshly authenticated user. The returned
+// session carries the plain refresh token, which is never stored.
+func (s *service) StartSession(userID int, userAgent, ipAddress string) (Session, error) {
+	var session Session
+
+	token, err := helper.NewToken()
+	if err != nil {
+		return session, err
+	}
+
+	session.UserID = userID
+	session.TokenHash = helper.HashToken(token)
+	session.UserAgent = truncate(userAgent, 255)
+	session.IPAddress = ipAddress
+	session.ExpiresAt = time.Now().Add(s.refreshTTL)
+
+	session, err = s.repository.Save(session)
+	if err != nil {
+		return session, err
+	}
+	session.RefreshToken = token
+
+	return session, nil
+}
+
+// Refresh exchanges a refresh token for a new one on the same session. Each
+// token works once: presenting an already rotated token means it was copied,
+// so the whole session is revoked.
+func (s *service) Refresh(input RefreshTokenInput, userAgent, ipAddress string) (Session, error) {
+	if input.RefreshToken == "" {
+		return Session{}, ErrInvalidRefreshToken
+	}
+
+	tokenHash := helper.HashToken(input.RefreshToken)
+
+	session, err := s.repository.FindByTokenHash(tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		if reused, err := s.repository.FindByPreviousTokenHash(tokenHash); err == nil {
+			if err := s.repository.Delete(reused); err != nil {
+				return session, err
+			}
+		}
+
+		return session, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return session, err
+	}
+
+	if !time.Now().Before(session.ExpiresAt) {
+		return session, ErrInvalidRefreshToken
+	}
+
+	token, err := helper.NewToken()
+	if err != nil {
+		return session, err
+	}
+
+	session.TokenHash = helper.HashToken(token)
+	session.UserAgent = truncate(userAgent, 255)
+	session.IPAddress = ipAddress
+	session.ExpiresAt = time.Now().Add(s.refreshTTL)
+
+	// Rotate only succeeds while tokenHash is still current, so of two
+	// concurrent refreshes with the same token only one wins
+	session, err = s.repository.Rotate(session, tokenHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return session, err
+	}
+	session.RefreshToken = token
+
+	return session, nil
+}
+
+func (s *service) FindSession(userID, sessionID int) (Session, error) {
+	session, err := s.repository.FindByID(userID, sessionID)
+
+	return session, helper.NoRows(err, ErrNotFound)
+}
+
+func (s *service) FindSessions(userID int) ([]Session, error) {
+	var sessions []Session
+
+	if userID == 0 {
+		return sessions, helper.ErrNoSession
+	}
+
+	return s.repository.FindByUserID(userID)
+}
+
+func (s *service) RevokeSession(userID, sessionID int) error {
+	err := s.repository.Delete(Session{ID: sessionID, UserID: userID})
+
+	return helper.NoRows(err, ErrNotFound)
+}
+
+func (s *service) RevokeOtherSessions(userID, currentSessionID int) error {
+	return s.repository.DeleteByUserID(userID, currentSessionID)
+}
+
+func (s *service) PurgeExpiredSessions(before time.Time) error {
+	return s.repository.DeleteExpiredBefore(before)
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
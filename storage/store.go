@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("blob doesn't exist")
+	ErrInvalidKey = errors.New("blob key may only hold letters, digits, '-', '_', '.' and '/'")
+)
+
+// BlobStore keeps file contents out of the database. Keys are chosen by the
+// caller and look like paths, e.g. attachments/12/3f9a...
+type BlobStore interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// checkKey keeps keys to characters that need no escaping in a path or URL,
+// and out of parent directories.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return ErrInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == '/':
+		default:
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/attachment"
+	"github.com/iqbaleff214/easynote-backend-go/folder"
+	"github.com/iqbaleff214/easynote-backend-go/note"
+	"github.com/iqbaleff214/easynote-backend-go/session"
+	"github.com/iqbaleff214/easynote-backend-go/user"
+)
+
+// sweepTrash permanently deletes trashed notes and folders once they have
+// been in the trash for longer than the retention period.
+func sweepTrash(noteService note.Service, folderService folder.Service, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// deleted_at is always stamped in UTC
+		before := time.Now().UTC().Add(-retention)
+
+		if err := noteService.PurgeTrashedNotes(before); err != nil {
+			log.Println("trash sweeper:", err)
+		}
+
+		if err := folderService.PurgeTrashedFolders(before); err != nil {
+			log.Println("trash sweeper:", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// sweepSessions deletes sessions whose refresh token has expired.
+func sweepSessions(sessionService session.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := sessionService.PurgeExpiredSessions(time.Now()); err != nil {
+			log.Println("session sweeper:", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// sweepLoginAttempts forgets failed logins a day after the last one, so old
+// failures don't count towards a lockout forever.
+func sweepLoginAttempts(userService user.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := userService.PurgeLoginAttempts(time.Now().Add(-time.Hour * 24)); err != nil {
+			log.Println("login attempt sweeper:", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// sweepAttachments deletes the files of attachments whose note was purged.
+func sweepAttachments(attachmentService attachment.Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := attachmentService.PurgeOrphanedAttachments(); err != nil {
+			log.Println("attachment sweeper:", err)
+		}
+
+		<-ticker.C
+	}
+}
@@ -0,0 +1,41 @@
+package transaction
+
+import "database/sql"
+
+// DBTX is the part of *sql.DB and *sql.Tx the repositories use, so the same
+// repository code runs either directly on the pool or inside a transaction.
+type DBTX interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Manager runs a unit of work inside a single database transaction.
+// Repositories join it through their WithTx method.
+type Manager interface {
+	Run(fn func(tx *sql.Tx) error) error
+}
+
+type manager struct {
+	db *sql.DB
+}
+
+func NewManager(db *sql.DB) *manager {
+	return &manager{db}
+}
+
+// Run commits the transaction when fn succeeds and rolls it back when fn
+// returns an error or panics.
+func (m *manager) Run(fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
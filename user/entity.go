@@ -3,10 +3,37 @@ package user
 import "time"
 
 type User struct {
+	ID              int
+	Name            string
+	Email           string
+	Password        string
+	EmailVerifiedAt time.Time
+	// TwoFactorSecret is set from enrollment on, but two-factor login is only
+	// enforced once TwoFactorConfirmedAt is set as well
+	TwoFactorSecret      string
+	TwoFactorConfirmedAt time.Time
+	TwoFactorLastStep    int64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+func (u User) TwoFactorEnabled() bool {
+	return u.TwoFactorSecret != "" && !u.TwoFactorConfirmedAt.IsZero()
+}
+
+type Token struct {
 	ID        int
-	Name      string
+	UserID    int
+	Type      string
+	TokenHash string
 	Email     string
-	Password  string
+	ExpiresAt time.Time
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type LoginAttempt struct {
+	Email       string
+	Failures    int
+	LockedUntil time.Time
+	UpdatedAt   time.Time
 }
@@ -0,0 +1,17 @@
+package user
+
+import "github.com/iqbaleff214/easynote-backend-go/helper"
+
+var (
+	ErrNotFound              = helper.NewError(helper.ErrNotFound, "user_not_found", "user doesn't exist")
+	ErrEmailTaken            = helper.NewError(helper.ErrConflict, "email_taken", "email has already been taken")
+	ErrInvalidCredentials    = helper.NewError(helper.ErrUnauthorized, "invalid_credentials", "invalid email or password")
+	ErrWrongPassword         = helper.NewError(helper.ErrUnauthorized, "wrong_password", "wrong password")
+	ErrInvalidToken          = helper.NewError(helper.ErrValidation, "invalid_token", "token is invalid or expired")
+	ErrEmailAlreadyVerified  = helper.NewError(helper.ErrConflict, "email_already_verified", "email has already been verified")
+	ErrInvalidChallenge      = helper.NewError(helper.ErrUnauthorized, "invalid_challenge", "login challenge is invalid or expired")
+	ErrInvalidTwoFactorCode  = helper.NewError(helper.ErrUnauthorized, "invalid_two_factor_code", "invalid two-factor code")
+	ErrTwoFactorDisabled     = helper.NewError(helper.ErrConflict, "two_factor_disabled", "two-factor authentication isn't enabled")
+	ErrTwoFactorEnabled      = helper.NewError(helper.ErrConflict, "two_factor_enabled", "two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolling = helper.NewError(helper.ErrConflict, "two_factor_not_enrolling", "two-factor enrollment hasn't been started")
+)
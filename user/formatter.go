@@ -1,10 +1,27 @@
 package user
 
 type UserFormatter struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Token string `json:"token,omitempty"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	TwoFactor     bool   `json:"two_factor_enabled"`
+	Token         string `json:"token,omitempty"`
+	RefreshToken  string `json:"refresh_token,omitempty"`
+}
+
+type TwoFactorChallengeFormatter struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+type TwoFactorSetupFormatter struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesFormatter struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 func FormatUser(user User, token string) UserFormatter {
@@ -12,6 +29,35 @@ func FormatUser(user User, token string) UserFormatter {
 		ID: user.ID,
 		Name: user.Name,
 		Email: user.Email,
+		EmailVerified: !user.EmailVerifiedAt.IsZero(),
+		TwoFactor: user.TwoFactorEnabled(),
 		Token: token,
 	}
 }
+
+func FormatAuthenticatedUser(user User, token, refreshToken string) UserFormatter {
+	userFormatter := FormatUser(user, token)
+	userFormatter.RefreshToken = refreshToken
+
+	return userFormatter
+}
+
+func FormatTwoFactorChallenge(challengeToken string) TwoFactorChallengeFormatter {
+	return TwoFactorChallengeFormatter{
+		TwoFactorRequired: true,
+		ChallengeToken:    challengeToken,
+	}
+}
+
+func FormatTwoFactorSetup(user User, provisioningURI string) TwoFactorSetupFormatter {
+	return TwoFactorSetupFormatter{
+		Secret:          user.TwoFactorSecret,
+		ProvisioningURI: provisioningURI,
+	}
+}
+
+func FormatRecoveryCodes(codes []string) RecoveryCodesFormatter {
+	return RecoveryCodesFormatter{
+		RecoveryCodes: codes,
+	}
+}
@@ -1,18 +1,45 @@
 package user
 
 type RegisterUserInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,password,max=72"`
 }
 
 type LoginInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
 }
 
 type UpdateUserInput struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"password,max=72"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password,max=72"`
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,max=10"`
+	RecoveryCode   string `json:"recovery_code" validate:"max=20"`
+}
+
+type ConfirmTwoFactorInput struct {
+	Code string `json:"code" validate:"required,max=10"`
+}
+
+type DisableTwoFactorInput struct {
+	Password string `json:"password" validate:"required,max=72"`
 }
@@ -0,0 +1,62 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LockedError is returned while an email is locked out after too many
+// failed logins.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, try again in %s", time.Until(e.Until).Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error {
+	return helper.ErrTooManyRequests
+}
+
+// RetryAfter tells how long until the email can try logging in again.
+func (e *LockedError) RetryAfter() time.Duration {
+	return time.Until(e.Until)
+}
+
+// LoginPolicy locks an email out once it has failed MaxAttempts logins in a
+// row. Every failure after that doubles the lockout, up to MaxLockout.
+type LoginPolicy struct {
+	MaxAttempts int
+	Lockout     time.Duration
+	MaxLockout  time.Duration
+}
+
+func (p LoginPolicy) lockoutFor(failures int) time.Duration {
+	if p.MaxAttempts <= 0 || failures < p.MaxAttempts {
+		return 0
+	}
+
+	lockout := p.Lockout
+	for i := p.MaxAttempts; i < failures && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+
+	if lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+
+	return lockout
+}
+
+// dummyPasswordHash is compared against when the email doesn't exist, so
+// unknown emails take as long to reject as wrong passwords.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("easynote"), bcrypt.MinCost)
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
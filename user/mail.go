@@ -0,0 +1,32 @@
+package user
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/iqbaleff214/easynote-backend-go/mail"
+)
+
+func verificationMail(user User, link string) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Verify your EasyNote email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm this is your email address by opening the link below:\n\n%s\n\n"+
+			"The link expires in 24 hours. If you didn't sign up for EasyNote, you can ignore this email.\n", user.Name, link),
+	}
+}
+
+func passwordResetMail(user User, link string) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Reset your EasyNote password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your EasyNote account. Open the link below to choose a new one:\n\n%s\n\n"+
+			"The link expires in an hour and works only once. If it wasn't you, you can ignore this email.\n", user.Name, link),
+	}
+}
+
+func tokenLink(appURL, path, token string) string {
+	return appURL + path + "?token=" + url.QueryEscape(token)
+}
@@ -3,23 +3,43 @@ package user
 import (
 	"database/sql"
 	"time"
+
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
 )
 
 type Repository interface {
+	WithTx(tx *sql.Tx) Repository
 	Save(user User) (User, error)
 	FindByEmail(email string) (User, error)
 	FindByID(id int) (User, error)
 	Update(user User) (User, error)
+	AdvanceTwoFactorStep(userID int, step int64) error
+	SaveToken(token Token) (Token, error)
+	FindToken(tokenType, tokenHash string) (Token, error)
+	DeleteToken(token Token) error
+	DeleteTokensByUserID(userID int, tokenType string) error
+	SaveRecoveryCodes(userID int, codeHashes []string) error
+	DeleteRecoveryCode(userID int, codeHash string) error
+	DeleteRecoveryCodes(userID int) error
+	FindLoginAttempt(email string) (LoginAttempt, error)
+	IncrementLoginFailures(email string) (LoginAttempt, error)
+	LockLogin(attempt LoginAttempt) error
+	DeleteLoginAttempt(email string) error
+	DeleteLoginAttemptsBefore(before time.Time) error
 }
 
 type repository struct {
-	db *sql.DB
+	db transaction.DBTX
 }
 
 func NewRepository(db *sql.DB) *repository {
 	return &repository{db}
 }
 
+func (r *repository) WithTx(tx *sql.Tx) Repository {
+	return &repository{tx}
+}
+
 func (r *repository) Save(user User) (User, error) {
 	query := "INSERT INTO users SET " +
 		"name = ?, email = ?, password = ?, created_at = NOW(), updated_at = NOW()"
@@ -40,41 +60,246 @@ func (r *repository) Save(user User) (User, error) {
 }
 
 func (r *repository) FindByEmail(email string) (User, error) {
-	var user User
+	query := "SELECT " + userColumns + " FROM users WHERE email = ?"
 
-	query := "SELECT id, name, email, password, created_at, updated_at FROM users WHERE email = ?"
+	return scanUser(r.db.QueryRow(query, email))
+}
 
-	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		return user, err
-	}
+func (r *repository) FindByID(id int) (User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
 
-	return user, nil
+	return scanUser(r.db.QueryRow(query, id))
 }
 
-func (r *repository) FindByID(id int) (User, error) {
-	var user User
+const userColumns = "id, name, email, password, email_verified_at, COALESCE(two_factor_secret, ''), two_factor_confirmed_at, " +
+	"two_factor_last_step, created_at, updated_at"
 
-	query := "SELECT id, name, email, password, created_at, updated_at FROM users WHERE id = ?"
+func scanUser(row *sql.Row) (User, error) {
+	var user User
+	var emailVerifiedAt, twoFactorConfirmedAt sql.NullTime
 
-	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err := row.Scan(
+		&user.ID, &user.Name, &user.Email, &user.Password, &emailVerifiedAt, &user.TwoFactorSecret,
+		&twoFactorConfirmedAt, &user.TwoFactorLastStep, &user.CreatedAt, &user.UpdatedAt,
+	)
 	if err != nil {
 		return user, err
 	}
+	user.EmailVerifiedAt = emailVerifiedAt.Time
+	user.TwoFactorConfirmedAt = twoFactorConfirmedAt.Time
 
 	return user, nil
 }
 
 func (r *repository) Update(user User) (User, error) {
+	var emailVerifiedAt, twoFactorSecret, twoFactorConfirmedAt any
+	if !user.EmailVerifiedAt.IsZero() {
+		emailVerifiedAt = user.EmailVerifiedAt.UTC()
+	}
+	if user.TwoFactorSecret != "" {
+		twoFactorSecret = user.TwoFactorSecret
+	}
+	if !user.TwoFactorConfirmedAt.IsZero() {
+		twoFactorConfirmedAt = user.TwoFactorConfirmedAt.UTC()
+	}
+
 	query := "UPDATE users SET " +
-		"name = ?, email = ?, password = ?, updated_at = NOW() " +
+		"name = ?, email = ?, password = ?, email_verified_at = ?, two_factor_secret = ?, two_factor_confirmed_at = ?, " +
+		"two_factor_last_step = ?, updated_at = NOW() " +
 		"WHERE id = ?"
 
 	user.UpdatedAt = time.Now()
-	_, err := r.db.Exec(query, user.Name, user.Email, user.Password, user.ID)
+	_, err := r.db.Exec(query,
+		user.Name, user.Email, user.Password, emailVerifiedAt, twoFactorSecret, twoFactorConfirmedAt,
+		user.TwoFactorLastStep, user.ID,
+	)
 	if err != nil {
 		return user, err
 	}
 
 	return user, nil
 }
+
+// AdvanceTwoFactorStep moves the last used TOTP step forward, failing with
+// sql.ErrNoRows when a concurrent login already used this step or a later one.
+func (r *repository) AdvanceTwoFactorStep(userID int, step int64) error {
+	query := "UPDATE users SET two_factor_last_step = ?, updated_at = NOW() " +
+		"WHERE id = ? AND two_factor_last_step < ?"
+
+	res, err := r.db.Exec(query, step, userID, step)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) SaveToken(token Token) (Token, error) {
+	query := "INSERT INTO user_tokens SET " +
+		"user_id = ?, type = ?, token_hash = ?, email = ?, expires_at = ?, created_at = NOW()"
+
+	res, err := r.db.Exec(query, token.UserID, token.Type, token.TokenHash, token.Email, token.ExpiresAt.UTC())
+	if err != nil {
+		return token, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return token, err
+	}
+
+	token.ID = int(id)
+	token.CreatedAt = time.Now()
+
+	return token, nil
+}
+
+func (r *repository) FindToken(tokenType, tokenHash string) (Token, error) {
+	var token Token
+
+	query := "SELECT id, user_id, type, token_hash, email, expires_at, created_at FROM user_tokens " +
+		"WHERE type = ? AND token_hash = ? AND expires_at > UTC_TIMESTAMP()"
+
+	err := r.db.QueryRow(query, tokenType, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.Type, &token.TokenHash, &token.Email, &token.ExpiresAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return token, err
+	}
+
+	return token, nil
+}
+
+// DeleteToken uses up the token. It fails with sql.ErrNoRows when the token
+// was already used, so a token can't be redeemed twice concurrently.
+func (r *repository) DeleteToken(token Token) error {
+	query := "DELETE FROM user_tokens WHERE id = ?"
+
+	res, err := r.db.Exec(query, token.ID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteTokensByUserID(userID int, tokenType string) error {
+	query := "DELETE FROM user_tokens WHERE user_id = ? AND type = ?"
+
+	_, err := r.db.Exec(query, userID, tokenType)
+	return err
+}
+
+// SaveRecoveryCodes replaces the user's recovery codes.
+func (r *repository) SaveRecoveryCodes(userID int, codeHashes []string) error {
+	if err := r.DeleteRecoveryCodes(userID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO two_factor_recovery_codes SET user_id = ?, code_hash = ?, created_at = NOW()"
+
+	for _, codeHash := range codeHashes {
+		if _, err := r.db.Exec(query, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteRecoveryCode uses up a recovery code, failing with sql.ErrNoRows when
+// the user has no such code left.
+func (r *repository) DeleteRecoveryCode(userID int, codeHash string) error {
+	query := "DELETE FROM two_factor_recovery_codes WHERE user_id = ? AND code_hash = ?"
+
+	res, err := r.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteRecoveryCodes(userID int) error {
+	query := "DELETE FROM two_factor_recovery_codes WHERE user_id = ?"
+
+	_, err := r.db.Exec(query, userID)
+	return err
+}
+
+func (r *repository) FindLoginAttempt(email string) (LoginAttempt, error) {
+	var attempt LoginAttempt
+	var lockedUntil sql.NullTime
+
+	query := "SELECT email, failures, locked_until, updated_at FROM login_attempts WHERE email = ?"
+
+	err := r.db.QueryRow(query, email).Scan(&attempt.Email, &attempt.Failures, &lockedUntil, &attempt.UpdatedAt)
+	if err != nil {
+		return attempt, err
+	}
+	attempt.LockedUntil = lockedUntil.Time
+
+	return attempt, nil
+}
+
+// IncrementLoginFailures counts a failed login in a single statement, so
+// concurrent guesses can't overwrite each other's count. Login attempts are
+// stamped in UTC, which is what the sweeper compares them against.
+func (r *repository) IncrementLoginFailures(email string) (LoginAttempt, error) {
+	query := "INSERT INTO login_attempts SET email = ?, failures = 1, created_at = UTC_TIMESTAMP(), updated_at = UTC_TIMESTAMP() " +
+		"ON DUPLICATE KEY UPDATE failures = failures + 1, updated_at = UTC_TIMESTAMP()"
+
+	if _, err := r.db.Exec(query, email); err != nil {
+		return LoginAttempt{}, err
+	}
+
+	return r.FindLoginAttempt(email)
+}
+
+func (r *repository) LockLogin(attempt LoginAttempt) error {
+	query := "UPDATE login_attempts SET locked_until = ?, updated_at = UTC_TIMESTAMP() WHERE email = ?"
+
+	_, err := r.db.Exec(query, attempt.LockedUntil.UTC(), attempt.Email)
+	return err
+}
+
+func (r *repository) DeleteLoginAttempt(email string) error {
+	query := "DELETE FROM login_attempts WHERE email = ?"
+
+	_, err := r.db.Exec(query, email)
+	return err
+}
+
+func (r *repository) DeleteLoginAttemptsBefore(before time.Time) error {
+	query := "DELETE FROM login_attempts WHERE updated_at < ? AND (locked_until IS NULL OR locked_until < UTC_TIMESTAMP())"
+
+	_, err := r.db.Exec(query, before.UTC())
+	return err
+}
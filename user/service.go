@@ -1,8 +1,14 @@
 package user
 
 import (
+	"database/sql"
 	"errors"
+	"log"
+	"time"
 
+	"github.com/iqbaleff214/easynote-backend-go/helper"
+	"github.com/iqbaleff214/easynote-backend-go/mail"
+	"github.com/iqbaleff214/easynote-backend-go/transaction"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -10,15 +16,30 @@ type Service interface {
 	RegisterUser(input RegisterUserInput) (User, error)
 	Login(input LoginInput) (User, error)
 	GetUserByID(id int) (User, error)
+	GetUserByEmail(email string) (User, error)
 	UpdateUser(input UpdateUserInput, currentUser User) (User, error)
+	SendVerification(user User) error
+	VerifyEmail(input VerifyEmailInput) (User, error)
+	ForgotPassword(input ForgotPasswordInput) error
+	ResetPassword(input ResetPasswordInput) (User, error)
+	StartTwoFactorChallenge(user User) (string, error)
+	LoginWithTwoFactor(input TwoFactorLoginInput) (User, error)
+	EnableTwoFactor(user User) (User, string, error)
+	ConfirmTwoFactor(input ConfirmTwoFactorInput, user User) ([]string, error)
+	DisableTwoFactor(input DisableTwoFactorInput, user User) error
+	PurgeLoginAttempts(before time.Time) error
 }
 
 type service struct {
-	repository Repository
+	repository   Repository
+	transactions transaction.Manager
+	mailer       mail.Mailer
+	appURL       string
+	loginPolicy  LoginPolicy
 }
 
-func NewService(repository Repository) *service {
-	return &service{repository}
+func NewService(repository Repository, transactions transaction.Manager, mailer mail.Mailer, appURL string, loginPolicy LoginPolicy) *service {
+	return &service{repository, transactions, mailer, appURL, loginPolicy}
 }
 
 func (s *service) RegisterUser(input RegisterUserInput) (User, error) {
@@ -36,51 +57,108 @@ func (s *service) RegisterUser(input RegisterUserInput) (User, error) {
 
 
 	newUser, err := s.repository.Save(user)
+	if helper.IsDuplicateEntry(err) {
+		return user, ErrEmailTaken
+	}
 	if err != nil {
 		return user, err
 	}
 
+	// The account is usable before it's verified, so a mail hiccup
+	// shouldn't fail the registration; the user can ask for another one
+	if err := s.SendVerification(newUser); err != nil {
+		log.Println("verification mail:", err)
+	}
+
 	return newUser, nil
 }
 
+// Login checks the credentials, answering the same way whether the email
+// is unknown or the password is wrong. Failed logins count against the email
+// whether or not it belongs to a user, so lockouts don't reveal accounts
+// either.
 func (s *service) Login(input LoginInput) (User, error) {
-	email := input.Email
+	email := normalizeEmail(input.Email)
 	pass := input.Password
 
+	attempt, err := s.repository.FindLoginAttempt(email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return User{}, err
+	}
+
+	if time.Now().Before(attempt.LockedUntil) {
+		return User{}, &LockedError{attempt.LockedUntil}
+	}
+
 	user, err := s.repository.FindByEmail(email)
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return user, err
 	}
 
-	if user.ID == 0 {
-		return user, errors.New("email has not been registered by any user")
+	passwordHash := dummyPasswordHash
+	if user.ID != 0 {
+		passwordHash = []byte(user.Password)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(passwordHash, []byte(pass)); err != nil || user.ID == 0 {
+		return User{}, s.failLogin(email, ErrInvalidCredentials)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(pass)); err != nil {
-		return user, errors.New("wrong password")
+	// With two-factor on the login isn't done yet, so the failures are only
+	// cleared once the code checks out too
+	if attempt.Failures > 0 && !user.TwoFactorEnabled() {
+		if err := s.repository.DeleteLoginAttempt(email); err != nil {
+			return user, err
+		}
 	}
 
 	return user, nil
 }
 
-func (s *service) GetUserByID(id int) (User, error) {
-	user, err := s.repository.FindByID(id)
+// failLogin records a failed login and locks the email out once the policy
+// says so, otherwise it answers with invalid.
+func (s *service) failLogin(email string, invalid error) error {
+	attempt, err := s.repository.IncrementLoginFailures(email)
 	if err != nil {
-		return user, err
+		return err
 	}
 
-	if user.ID == 0 {
-		return user, errors.New("user doesn't exists")
+	lockout := s.loginPolicy.lockoutFor(attempt.Failures)
+	if lockout == 0 {
+		return invalid
 	}
 
-	return user, nil
+	attempt.LockedUntil = time.Now().Add(lockout)
+	if err := s.repository.LockLogin(attempt); err != nil {
+		return err
+	}
+
+	return &LockedError{attempt.LockedUntil}
+}
+
+func (s *service) GetUserByID(id int) (User, error) {
+	user, err := s.repository.FindByID(id)
+
+	return user, helper.NoRows(err, ErrNotFound)
+}
+
+func (s *service) GetUserByEmail(email string) (User, error) {
+	user, err := s.repository.FindByEmail(email)
+
+	return user, helper.NoRows(err, ErrNotFound)
 }
 
 func (s *service) UpdateUser(input UpdateUserInput, currentUser User) (User, error) {
 
+	emailChanged := input.Email != currentUser.Email
+
 	currentUser.Name = input.Name
 	currentUser.Email = input.Email
 
+	if emailChanged {
+		currentUser.EmailVerifiedAt = time.Time{}
+	}
+
 	if input.Password != "" {
 		passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.MinCost)
 		if err != nil {
@@ -90,9 +168,362 @@ func (s *service) UpdateUser(input UpdateUserInput, currentUser User) (User, err
 	}
 
 	newUser, err := s.repository.Update(currentUser)
+	if helper.IsDuplicateEntry(err) {
+		return currentUser, ErrEmailTaken
+	}
 	if err != nil {
 		return currentUser, err
 	}
 
+	if emailChanged {
+		if err := s.SendVerification(newUser); err != nil {
+			log.Println("verification mail:", err)
+		}
+	}
+
 	return newUser, nil
 }
+
+// SendVerification mails the user a link proving they own their current
+// email address. Links sent earlier stop working.
+func (s *service) SendVerification(user User) error {
+	if !user.EmailVerifiedAt.IsZero() {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(user, TokenEmailVerification)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(verificationMail(user, tokenLink(s.appURL, "/verify-email", token)))
+}
+
+func (s *service) VerifyEmail(input VerifyEmailInput) (User, error) {
+	var user User
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		token, err := redeemToken(repository, TokenEmailVerification, input.Token)
+		if err != nil {
+			return err
+		}
+
+		user, err = repository.FindByID(token.UserID)
+		if err != nil {
+			return err
+		}
+
+		// The user changed their email after this link was sent
+		if token.Email != user.Email {
+			return ErrInvalidToken
+		}
+
+		user.EmailVerifiedAt = time.Now()
+
+		user, err = repository.Update(user)
+		return err
+	})
+
+	return user, err
+}
+
+// ForgotPassword mails a reset link when the email belongs to a user. It
+// doesn't tell whether it does, so it can't be used to probe for accounts.
+func (s *service) ForgotPassword(input ForgotPasswordInput) error {
+	user, err := s.repository.FindByEmail(input.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := s.issueToken(user, TokenPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(passwordResetMail(user, tokenLink(s.appURL, "/reset-password", token)))
+}
+
+func (s *service) ResetPassword(input ResetPasswordInput) (User, error) {
+	var user User
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.MinCost)
+	if err != nil {
+		return user, err
+	}
+
+	err = s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		token, err := redeemToken(repository, TokenPasswordReset, input.Token)
+		if err != nil {
+			return err
+		}
+
+		user, err = repository.FindByID(token.UserID)
+		if err != nil {
+			return err
+		}
+
+		user.Password = string(passwordHash)
+
+		user, err = repository.Update(user)
+		if err != nil {
+			return err
+		}
+
+		// Whoever was guessing the old password is locked out already
+		if err := repository.DeleteLoginAttempt(normalizeEmail(user.Email)); err != nil {
+			return err
+		}
+
+		return repository.DeleteTokensByUserID(user.ID, TokenPasswordReset)
+	})
+
+	return user, err
+}
+
+// StartTwoFactorChallenge returns the token a user with two-factor enabled
+// trades, along with a code, for a session once their password checked out.
+func (s *service) StartTwoFactorChallenge(user User) (string, error) {
+	if !user.TwoFactorEnabled() {
+		return "", ErrTwoFactorDisabled
+	}
+
+	return s.issueToken(user, TokenTwoFactorLogin)
+}
+
+// LoginWithTwoFactor completes a login challenge with either a code from the
+// authenticator app or one of the recovery codes. A wrong code leaves the
+// challenge usable until it expires, but counts toward the same lockout as a
+// wrong password.
+func (s *service) LoginWithTwoFactor(input TwoFactorLoginInput) (User, error) {
+	var user User
+	var attempt LoginAttempt
+
+	err := s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		token, err := repository.FindToken(TokenTwoFactorLogin, helper.HashToken(input.ChallengeToken))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidChallenge
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = repository.FindByID(token.UserID)
+		if err != nil {
+			return err
+		}
+
+		attempt, err = repository.FindLoginAttempt(normalizeEmail(user.Email))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if time.Now().Before(attempt.LockedUntil) {
+			return &LockedError{attempt.LockedUntil}
+		}
+
+		if !user.TwoFactorEnabled() {
+			return ErrInvalidTwoFactorCode
+		}
+
+		if input.RecoveryCode != "" {
+			err := repository.DeleteRecoveryCode(user.ID, helper.HashToken(normalizeRecoveryCode(input.RecoveryCode)))
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrInvalidTwoFactorCode
+			}
+			if err != nil {
+				return err
+			}
+		} else {
+			step, ok := verifyTOTP(user.TwoFactorSecret, input.Code, time.Now(), user.TwoFactorLastStep)
+			if !ok {
+				return ErrInvalidTwoFactorCode
+			}
+
+			// Conditional, so two requests racing with the same code can't both
+			// get through
+			err := repository.AdvanceTwoFactorStep(user.ID, step)
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrInvalidTwoFactorCode
+			}
+			if err != nil {
+				return err
+			}
+
+			user.TwoFactorLastStep = step
+		}
+
+		err = repository.DeleteToken(token)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidChallenge
+		}
+		if err != nil {
+			return err
+		}
+
+		if attempt.Failures > 0 {
+			return repository.DeleteLoginAttempt(attempt.Email)
+		}
+
+		return nil
+	})
+
+	// Recorded outside the transaction, which a wrong code rolls back
+	if errors.Is(err, ErrInvalidTwoFactorCode) && user.ID != 0 {
+		return User{}, s.failLogin(normalizeEmail(user.Email), ErrInvalidTwoFactorCode)
+	}
+
+	return user, err
+}
+
+// EnableTwoFactor generates a new secret and returns the provisioning URI for
+// it. Two-factor login is enforced only after ConfirmTwoFactor.
+func (s *service) EnableTwoFactor(user User) (User, string, error) {
+	if user.TwoFactorEnabled() {
+		return user, "", ErrTwoFactorEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return user, "", err
+	}
+
+	user.TwoFactorSecret = secret
+	user.TwoFactorConfirmedAt = time.Time{}
+	user.TwoFactorLastStep = 0
+
+	user, err = s.repository.Update(user)
+	if err != nil {
+		return user, "", err
+	}
+
+	return user, totpURI("EasyNote", user.Email, secret), nil
+}
+
+// ConfirmTwoFactor turns two-factor on once the user proves their app
+// generates valid codes, and returns their recovery codes. The codes are
+// only stored hashed, so this is the only time they can be shown.
+func (s *service) ConfirmTwoFactor(input ConfirmTwoFactorInput, user User) ([]string, error) {
+	if user.TwoFactorSecret == "" {
+		return nil, ErrTwoFactorNotEnrolling
+	}
+
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := verifyTOTP(user.TwoFactorSecret, input.Code, time.Now(), user.TwoFactorLastStep)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := newRecoveryCodes(10)
+	if err != nil {
+		return nil, err
+	}
+
+	var codeHashes []string
+	for _, code := range codes {
+		codeHashes = append(codeHashes, helper.HashToken(code))
+	}
+
+	user.TwoFactorConfirmedAt = time.Now()
+	user.TwoFactorLastStep = step
+
+	err = s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		if _, err := repository.Update(user); err != nil {
+			return err
+		}
+
+		return repository.SaveRecoveryCodes(user.ID, codeHashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *service) DisableTwoFactor(input DisableTwoFactorInput, user User) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		return ErrWrongPassword
+	}
+
+	user.TwoFactorSecret = ""
+	user.TwoFactorConfirmedAt = time.Time{}
+	user.TwoFactorLastStep = 0
+
+	return s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		if _, err := repository.Update(user); err != nil {
+			return err
+		}
+
+		if err := repository.DeleteTokensByUserID(user.ID, TokenTwoFactorLogin); err != nil {
+			return err
+		}
+
+		return repository.DeleteRecoveryCodes(user.ID)
+	})
+}
+
+// PurgeLoginAttempts forgets the failed logins of emails left alone since
+// before, once they aren't locked anymore.
+func (s *service) PurgeLoginAttempts(before time.Time) error {
+	return s.repository.DeleteLoginAttemptsBefore(before)
+}
+
+// issueToken replaces any outstanding token of the type with a new one and
+// returns its plain value.
+func (s *service) issueToken(user User, tokenType string) (string, error) {
+	plain, err := helper.NewToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.transactions.Run(func(tx *sql.Tx) error {
+		repository := s.repository.WithTx(tx)
+
+		if err := repository.DeleteTokensByUserID(user.ID, tokenType); err != nil {
+			return err
+		}
+
+		_, err := repository.SaveToken(Token{
+			UserID:    user.ID,
+			Type:      tokenType,
+			TokenHash: helper.HashToken(plain),
+			Email:     user.Email,
+			ExpiresAt: time.Now().Add(tokenTTLs[tokenType]),
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return plain, nil
+}
+
+// redeemToken looks up an unexpired token and uses it up.
+func redeemToken(repository Repository, tokenType, plain string) (Token, error) {
+	token, err := repository.FindToken(tokenType, helper.HashToken(plain))
+	if err == nil {
+		err = repository.DeleteToken(token)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return token, ErrInvalidToken
+	}
+
+	return token, err
+}
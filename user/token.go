@@ -0,0 +1,15 @@
+package user
+
+import "time"
+
+const (
+	TokenPasswordReset     = "password_reset"
+	TokenEmailVerification = "email_verification"
+	TokenTwoFactorLogin    = "two_factor_login"
+)
+
+var tokenTTLs = map[string]time.Duration{
+	TokenPasswordReset:     time.Hour,
+	TokenEmailVerification: time.Hour * 24,
+	TokenTwoFactorLogin:    time.Minute * 5,
+}
@@ -0,0 +1,94 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of the RFC 6238 test vectors, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+
+	// The RFC lists eight digit codes, these are their last six
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(%d) = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+	code := func(step int64) string { return totpCode(key, step) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current period", rfcSecret, code(step), 0, step, true},
+		{"previous period", rfcSecret, code(step - 1), 0, step - 1, true},
+		{"next period", rfcSecret, code(step + 1), 0, step + 1, true},
+		{"outside the drift window", rfcSecret, code(step - 2), 0, 0, false},
+		{"lowercase secret", strings.ToLower(rfcSecret), code(step), 0, step, true},
+		{"spaces in the code", rfcSecret, code(step)[:3] + " " + code(step)[3:], 0, step, true},
+		{"wrong code", rfcSecret, "000000", 0, 0, false},
+		{"replayed code", rfcSecret, code(step), step, 0, false},
+		{"code of an earlier period than the last one used", rfcSecret, code(step - 1), step, 0, false},
+		{"code of a period after the last one used", rfcSecret, code(step + 1), step, step + 1, true},
+		{"empty code", rfcSecret, "", 0, 0, false},
+		{"short code", rfcSecret, code(step)[:5], 0, 0, false},
+		{"long code", rfcSecret, code(step) + "0", 0, 0, false},
+		{"malformed secret", "not base32!", code(step), 0, 0, false},
+		{"empty secret", "", code(step), 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, gotOK := verifyTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if gotStep != tt.wantStep || gotOK != tt.wantOK {
+				t.Errorf("verifyTOTP() = %d, %v, want %d, %v", gotStep, gotOK, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatalf("newTOTPSecret() error = %v", err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("secret %q doesn't decode to a 20 byte key: %v", secret, err)
+	}
+
+	if _, ok := verifyTOTP(secret, totpCode(key, time.Now().Unix()/totpPeriod), time.Now(), 0); !ok {
+		t.Errorf("code of a new secret isn't accepted")
+	}
+}